@@ -0,0 +1,295 @@
+package word2vec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Cross-lingual alignment maps the vectors of a source model (e.g. Spanish) into the space of a
+// target model (e.g. English) trained separately, so translations are nearest neighbours.
+// python: https://github.com/facebookresearch/MUSE (supervised Procrustes refinement and CSLS)
+
+// DictionaryPair is a translation of a source word into a target word
+type DictionaryPair struct {
+	Source string
+	Target string
+}
+
+// ReadDictionary reads a bilingual dictionary file with one "source target" pair per line like the
+// MUSE dictionaries, a source word may have several lines
+func ReadDictionary(path string) ([]DictionaryPair, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ReadDictionaryReader(f)
+}
+
+// ReadDictionaryReader reads a bilingual dictionary from r
+func ReadDictionaryReader(r io.Reader) ([]DictionaryPair, error) {
+	pairs := make([]DictionaryPair, 0)
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		words := strings.Fields(s.Text())
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) != 2 {
+			return nil, fmt.Errorf("word2vec: expected 2 words on line %d, got %d", line, len(words))
+		}
+		pairs = append(pairs, DictionaryPair{Source: words[0], Target: words[1]})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// LearnAlignment returns the orthogonal matrix W minimizing |XW - Y| for the unit vectors X of the
+// source and Y of the target words of the dictionary: W = U V^T for the SVD U S V^T of X^T Y.
+// Pairs with a word out of vocabulary are skipped.
+func LearnAlignment(dictionary []DictionaryPair, source *Model, target *Model) (*mat64.Dense, error) {
+	if source.VecDim != target.VecDim {
+		return nil, &DimensionError{Word: "target", Expected: source.VecDim, Got: target.VecDim}
+	}
+
+	sourceNorm := normalizedVectors(source)
+	targetNorm := normalizedVectors(target)
+
+	m := mat64.NewDense(source.VecDim, source.VecDim, nil)
+	pairs := 0
+	for _, pair := range dictionary {
+		sourceIdx, ok := lookupWord(source, pair.Source)
+		if !ok {
+			continue
+		}
+		targetIdx, ok := lookupWord(target, pair.Target)
+		if !ok {
+			continue
+		}
+
+		var outer mat64.Dense
+		outer.Outer(1, sourceNorm.RowView(sourceIdx), targetNorm.RowView(targetIdx))
+		m.Add(m, &outer)
+		pairs++
+	}
+
+	if pairs == 0 {
+		return nil, errors.New("word2vec: no dictionary pair with both words in the vocabularies")
+	}
+
+	var svd mat64.SVD
+	if !svd.Factorize(m, matrix.SVDFull) {
+		return nil, errors.New("word2vec: SVD of the dictionary vectors did not converge")
+	}
+
+	var u, v mat64.Dense
+	u.UFromSVD(&svd)
+	v.VFromSVD(&svd)
+
+	w := mat64.NewDense(source.VecDim, source.VecDim, nil)
+	w.Mul(&u, v.T())
+	return w, nil
+}
+
+// ApplyAlignment returns a new model with the vectors of the model multiplied by w
+func ApplyAlignment(w *mat64.Dense, model *Model) (*Model, error) {
+	if r, c := w.Dims(); r != model.VecDim || c != model.VecDim {
+		return nil, &DimensionError{Word: "mapping", Expected: model.VecDim, Got: r}
+	}
+
+	data := make([]float64, len(model.Vocab)*model.VecDim)
+	for idx, phraseObj := range model.Vocab {
+		row := mat64.NewVector(model.VecDim, data[idx*model.VecDim:(idx+1)*model.VecDim])
+		row.MulVec(w.T(), phraseObj.Vector)
+	}
+
+	return newDerivedModel(model, data), nil
+}
+
+// AlignModels learns the alignment of source to target from the dictionary and applies it to source
+func AlignModels(dictionary []DictionaryPair, source *Model, target *Model) (*Model, error) {
+	w, err := LearnAlignment(dictionary, source, target)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyAlignment(w, source)
+}
+
+// cslsChunk is the number of target words scored at once when computing the CSLS radii
+const cslsChunk = 256
+
+// Translator retrieves translations of words of an aligned source model in a target model with
+// cross-domain similarity local scaling, CSLS(x, y) = 2 cos(x, y) - r_T(x) - r_S(y), where r_T(x)
+// is the mean similarity of x to its K nearest target words and r_S(y) that of y to its K nearest
+// source words. CSLS penalizes hubs, target words close to many source words.
+// after Conneau et al.: https://arxiv.org/abs/1710.04087
+type Translator struct {
+	K            int
+	Source       *Model
+	Target       *Model
+	targetRadius []float64 // r_S(y) of every target word
+}
+
+// NewTranslator precomputes r_S for every target word, k is usually 10. It multiplies the
+// vectors of both vocabularies, so its runtime grows with the product of their sizes.
+func NewTranslator(k int, aligned *Model, target *Model) (*Translator, error) {
+	if aligned.VecDim != target.VecDim {
+		return nil, &DimensionError{Word: "target", Expected: aligned.VecDim, Got: target.VecDim}
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("word2vec: CSLS needs at least one neighbour, got %d", k)
+	}
+
+	t := &Translator{
+		K:            k,
+		Source:       aligned,
+		Target:       target,
+		targetRadius: make([]float64, len(target.Vocab)),
+	}
+	if len(aligned.Vocab) == 0 {
+		return t, nil
+	}
+
+	sourceNorm := normalizedVectors(aligned)
+	targetNorm := normalizedVectors(target)
+	for lo := 0; lo < len(target.Vocab); lo += cslsChunk {
+		hi := minInt(lo+cslsChunk, len(target.Vocab))
+
+		var sims mat64.Dense
+		sims.Mul(targetNorm.View(lo, 0, hi-lo, target.VecDim), sourceNorm.T())
+		for i := lo; i < hi; i++ {
+			t.targetRadius[i] = meanTopK(sims.RawRowView(i-lo), k)
+		}
+	}
+
+	return t, nil
+}
+
+// Translate returns the top target words for a source word by CSLS, the scores are in SimPair.Sim
+func (t *Translator) Translate(word string, top int) ([]SimPair, error) {
+	idx, ok := lookupWord(t.Source, word)
+	if !ok {
+		return nil, &UnknownWordError{Word: word}
+	}
+	return t.translate(idx, top), nil
+}
+
+func (t *Translator) translate(idx int, top int) []SimPair {
+	if len(t.Target.Vocab) == 0 {
+		return []SimPair{}
+	}
+
+	sims := mat64.NewVector(len(t.Target.Vocab), nil)
+	sims.MulVec(normalizedVectors(t.Target), normalizedVectors(t.Source).RowView(idx))
+
+	scores := sims.RawVector().Data
+	sourceRadius := meanTopK(scores, t.K)
+	for i := range scores {
+		scores[i] = 2*scores[i] - sourceRadius - t.targetRadius[i]
+	}
+
+	return topSimilar(scores, top, nil, t.Target)
+}
+
+// meanTopK returns the mean of the k largest values
+func meanTopK(values []float64, k int) float64 {
+	k = minInt(k, len(values))
+	if k == 0 {
+		return 0
+	}
+
+	// largest is sorted in descending order
+	largest := make([]float64, 0, k+1)
+	for _, v := range values {
+		if len(largest) == k && v <= largest[k-1] {
+			continue
+		}
+		i := len(largest)
+		for i > 0 && largest[i-1] < v {
+			i--
+		}
+		largest = append(largest, 0)
+		copy(largest[i+1:], largest[i:])
+		largest[i] = v
+		if len(largest) > k {
+			largest = largest[:k]
+		}
+	}
+
+	sum := 0.0
+	for _, v := range largest {
+		sum += v
+	}
+	return sum / float64(k)
+}
+
+// TranslationReport holds the precision of the translations of a held-out dictionary
+type TranslationReport struct {
+	Words      int // source words with a translation in the target vocabulary
+	OOV        int // source words skipped because they or all their translations are out of vocabulary
+	CorrectAt1 int // words with a correct translation first
+	CorrectAt5 int // words with a correct translation in the top 5
+	Precision1 float64
+	Precision5 float64
+}
+
+func (r TranslationReport) String() string {
+	return fmt.Sprintf("P@1 %.4f, P@5 %.4f (%d words, %d out of vocabulary)",
+		r.Precision1, r.Precision5, r.Words, r.OOV)
+}
+
+// EvaluateTranslation translates every source word of the dictionary, a word counts as correct
+// if any of its translations in the dictionary is retrieved
+func EvaluateTranslation(dictionary []DictionaryPair, t *Translator) *TranslationReport {
+	order := make([]string, 0)
+	gold := make(map[string]map[int]bool)
+	for _, pair := range dictionary {
+		if _, ok := gold[pair.Source]; !ok {
+			order = append(order, pair.Source)
+			gold[pair.Source] = make(map[int]bool)
+		}
+		if idx, ok := lookupWord(t.Target, pair.Target); ok {
+			gold[pair.Source][idx] = true
+		}
+	}
+
+	report := &TranslationReport{}
+	for _, word := range order {
+		idx, ok := lookupWord(t.Source, word)
+		if !ok || len(gold[word]) == 0 {
+			report.OOV++
+			continue
+		}
+		report.Words++
+
+		for rank, pair := range t.translate(idx, 5) {
+			if !gold[word][t.Target.Word2Index[pair.Key]] {
+				continue
+			}
+			if rank == 0 {
+				report.CorrectAt1++
+			}
+			report.CorrectAt5++
+			break
+		}
+	}
+
+	if report.Words > 0 {
+		report.Precision1 = float64(report.CorrectAt1) / float64(report.Words)
+		report.Precision5 = float64(report.CorrectAt5) / float64(report.Words)
+	}
+	return report
+}
@@ -0,0 +1,144 @@
+package word2vec
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+)
+
+// rotatedModel returns a copy of model with words prefixed by "es_" and vectors rotated by a random
+// orthogonal matrix plus noise, and the rotation mapping it back to model
+func rotatedModel(model *Model, noise float64, seed int64) (*Model, *mat64.Dense) {
+	r := rand.New(rand.NewSource(seed))
+	dim := model.VecDim
+
+	random := mat64.NewDense(dim, dim, nil)
+	for i := 0; i < dim; i++ {
+		for j := 0; j < dim; j++ {
+			random.Set(i, j, r.NormFloat64())
+		}
+	}
+	var svd mat64.SVD
+	svd.Factorize(random, matrix.SVDFull)
+	var q mat64.Dense
+	q.UFromSVD(&svd)
+
+	words := make([]string, len(model.Vocab))
+	data := make([]float64, len(model.Vocab)*dim)
+	for idx, phraseObj := range model.Vocab {
+		words[idx] = "es_" + phraseObj.Literal
+		row := mat64.NewVector(dim, data[idx*dim:(idx+1)*dim])
+		row.MulVec(&q, phraseObj.Vector)
+		for i := 0; i < dim; i++ {
+			row.SetVec(i, row.At(i, 0)+noise*r.NormFloat64())
+		}
+	}
+	return newLoadedModel(words, data, dim), &q
+}
+
+func TestAlignModels(t *testing.T) {
+	fmt.Println("TestAlignModels")
+	target := randomModel(300, 8, 11)
+	source, q := rotatedModel(target, 0.05, 12)
+
+	train := make([]DictionaryPair, 0)
+	test := make([]DictionaryPair, 0)
+	for idx, phraseObj := range target.Vocab {
+		pair := DictionaryPair{Source: "es_" + phraseObj.Literal, Target: phraseObj.Literal}
+		if idx < 100 {
+			train = append(train, pair)
+		} else {
+			test = append(test, pair)
+		}
+	}
+	train = append(train, DictionaryPair{Source: "es_banana", Target: "banana"})
+
+	w, err := LearnAlignment(train, source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the rows of source are x^T Q^T, so the mapping x^T Q^T W = x^T recovers W = Q
+	var wtq mat64.Dense
+	wtq.Mul(w.T(), q)
+	for i := 0; i < target.VecDim; i++ {
+		if math.Abs(wtq.At(i, i)-1) > 0.05 {
+			t.Errorf("Got %f on the diagonal of W^T Q instead of 1", wtq.At(i, i))
+		}
+	}
+
+	aligned, err := ApplyAlignment(w, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aligned.Vocab) != len(source.Vocab) || aligned.Vocab[3].Count != source.Vocab[3].Count {
+		t.Fatalf("Got %d instead of %d aligned words", len(aligned.Vocab), len(source.Vocab))
+	}
+	for idx, phraseObj := range source.Vocab[:10] {
+		length := mat64.Norm(phraseObj.Vector, 2)
+		if got := mat64.Norm(aligned.Vocab[idx].Vector, 2); math.Abs(got-length) > 1e-9 {
+			t.Errorf("Got length %f instead of %f after the orthogonal mapping", got, length)
+		}
+	}
+
+	translator, err := NewTranslator(10, aligned, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	translations, err := translator.Translate("es_w150", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translations[0].Key != "w150" {
+		t.Errorf("Got %v instead of w150", translations)
+	}
+
+	report := EvaluateTranslation(append(test, DictionaryPair{Source: "es_banana", Target: "w1"}), translator)
+	if report.Words != 200 || report.OOV != 1 {
+		t.Errorf("Got %d words and %d out of vocabulary instead of 200 and 1", report.Words, report.OOV)
+	}
+	if report.Precision1 < 0.95 || report.Precision5 < report.Precision1 {
+		t.Errorf("Got %s", report)
+	}
+
+	// without the mapping the rotated vectors translate at random
+	unaligned, err := NewTranslator(10, source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseline := EvaluateTranslation(test, unaligned); baseline.Precision1 > 0.2 {
+		t.Errorf("Got %s without alignment", baseline)
+	}
+}
+
+func TestReadDictionary(t *testing.T) {
+	fmt.Println("TestReadDictionary")
+	pairs, err := ReadDictionaryReader(strings.NewReader("perro dog\n\ngato cat\ngato kitty\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 3 || pairs[2] != (DictionaryPair{"gato", "kitty"}) {
+		t.Errorf("Got %v", pairs)
+	}
+
+	if _, err := ReadDictionaryReader(strings.NewReader("perro dog\ngato cat kitty\n")); err == nil {
+		t.Error("Expected an error for a line with 3 words")
+	}
+}
+
+func TestMeanTopK(t *testing.T) {
+	fmt.Println("TestMeanTopK")
+	values := []float64{0.1, 0.9, -0.3, 0.5, 0.7, 0.2}
+	if got := meanTopK(values, 3); math.Abs(got-0.7) > 1e-12 {
+		t.Errorf("Got %f instead of 0.7", got)
+	}
+	if got := meanTopK(values[:2], 5); math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("Got %f instead of 0.5", got)
+	}
+}
@@ -0,0 +1,169 @@
+package word2vec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Word analogy evaluation on the questions-words format of the C tool:
+// ": section" headers followed by lines "a b c d" meaning a is to b as c is to d.
+// https://github.com/tmikolov/word2vec/blob/master/questions-words.txt
+//
+// Every question is answered with 3CosAdd (Mikolov) and 3CosMul (Levy & Goldberg):
+// https://www.aclweb.org/anthology/W14-1618
+
+// AnalogyResult counts the answers for one section of an analogy file
+type AnalogyResult struct {
+	Section    string
+	Questions  int // questions with all four words in the vocabulary
+	OOV        int // questions skipped because a word is out of vocabulary
+	CorrectAdd int // questions solved with 3CosAdd
+	CorrectMul int // questions solved with 3CosMul
+}
+
+// AccuracyAdd returns the share of answered questions solved with 3CosAdd
+func (r AnalogyResult) AccuracyAdd() float64 {
+	if r.Questions == 0 {
+		return 0
+	}
+	return float64(r.CorrectAdd) / float64(r.Questions)
+}
+
+// AccuracyMul returns the share of answered questions solved with 3CosMul
+func (r AnalogyResult) AccuracyMul() float64 {
+	if r.Questions == 0 {
+		return 0
+	}
+	return float64(r.CorrectMul) / float64(r.Questions)
+}
+
+func (r AnalogyResult) String() string {
+	return fmt.Sprintf("%s: 3CosAdd %.4f, 3CosMul %.4f (%d questions, %d out of vocabulary)",
+		r.Section, r.AccuracyAdd(), r.AccuracyMul(), r.Questions, r.OOV)
+}
+
+// AnalogyReport holds the per section and the overall results
+type AnalogyReport struct {
+	Sections []AnalogyResult
+	Total    AnalogyResult
+}
+
+// EvaluateAnalogies solves every question of the analogy file at path against the model
+func EvaluateAnalogies(path string, model *Model) (*AnalogyReport, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return EvaluateAnalogiesReader(f, model)
+}
+
+// EvaluateAnalogiesReader solves every question read from r against the model.
+// Words are looked up as given or in the form of the tokenizer of the model.
+func EvaluateAnalogiesReader(r io.Reader, model *Model) (*AnalogyReport, error) {
+	norm := normalizedVectors(model)
+	report := &AnalogyReport{Total: AnalogyResult{Section: "total"}}
+
+	var section *AnalogyResult
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, ":") {
+			report.Sections = append(report.Sections, AnalogyResult{Section: strings.TrimSpace(text[1:])})
+			section = &report.Sections[len(report.Sections)-1]
+			continue
+		}
+
+		if section == nil {
+			return nil, fmt.Errorf("word2vec: analogy on line %d is not part of a section", line)
+		}
+
+		words := strings.Fields(text)
+		if len(words) != 4 {
+			return nil, fmt.Errorf("word2vec: expected 4 words on line %d, got %d", line, len(words))
+		}
+
+		ids := make([]int, 4)
+		oov := false
+		for i, word := range words {
+			idx, ok := lookupWord(model, word)
+			if !ok {
+				oov = true
+				break
+			}
+			ids[i] = idx
+		}
+
+		if oov {
+			section.OOV++
+			report.Total.OOV++
+			continue
+		}
+
+		add, mul := solveAnalogy(norm, ids[0], ids[1], ids[2])
+
+		section.Questions++
+		report.Total.Questions++
+		if add == ids[3] {
+			section.CorrectAdd++
+			report.Total.CorrectAdd++
+		}
+		if mul == ids[3] {
+			section.CorrectMul++
+			report.Total.CorrectMul++
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// solveAnalogy answers a:b :: c:? with 3CosAdd and 3CosMul on unit length vectors,
+// the question words themselves are never an answer
+func solveAnalogy(norm *mat64.Dense, a, b, c int) (int, int) {
+	size, _ := norm.Dims()
+
+	simA := mat64.NewVector(size, nil)
+	simB := mat64.NewVector(size, nil)
+	simC := mat64.NewVector(size, nil)
+	simA.MulVec(norm, norm.RowView(a))
+	simB.MulVec(norm, norm.RowView(b))
+	simC.MulVec(norm, norm.RowView(c))
+
+	bestAdd, bestMul := -1, -1
+	maxAdd, maxMul := math.Inf(-1), math.Inf(-1)
+
+	for i := 0; i < size; i++ {
+		if i == a || i == b || i == c {
+			continue
+		}
+
+		ca, cb, cc := simA.At(i, 0), simB.At(i, 0), simC.At(i, 0)
+
+		// cos(x, b - a + c) ranks like the sum of the single cosines for unit x
+		if add := cb - ca + cc; add > maxAdd {
+			maxAdd, bestAdd = add, i
+		}
+
+		// 3CosMul shifts the cosines to [0, 1]
+		if mul := ((cb + 1) / 2) * ((cc + 1) / 2) / ((ca+1)/2 + 0.001); mul > maxMul {
+			maxMul, bestMul = mul, i
+		}
+	}
+
+	return bestAdd, bestMul
+}
@@ -0,0 +1,94 @@
+package word2vec
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// analogyModel has hand made vectors: royal, male, female, fruit
+func analogyModel() *Model {
+	words := []string{"king", "queen", "man", "woman", "apple", "pear"}
+	data := []float64{
+		1, 1, 0, 0.1,
+		1, 0, 1, 0.1,
+		0, 1, 0, 0.1,
+		0, 0, 1, 0.1,
+		0, 0, 0, 1,
+		0, 0.1, 0.1, 1,
+	}
+	return newLoadedModel(words, data, 4)
+}
+
+func TestEvaluateAnalogies(t *testing.T) {
+	fmt.Println("TestEvaluateAnalogies")
+	// question words are never answers, so "apple pear man apple" can not be solved
+	questions := `: family
+man woman King queen
+woman man queen king
+man king woman unicorn
+: fruit
+apple pear man apple
+`
+	report, err := EvaluateAnalogiesReader(strings.NewReader(questions), analogyModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Sections) != 2 {
+		t.Fatalf("Got %d instead of 2 sections", len(report.Sections))
+	}
+
+	family := report.Sections[0]
+	if family.Section != "family" || family.Questions != 2 || family.OOV != 1 {
+		t.Errorf("Got %v for the family section", family)
+	}
+	if family.CorrectAdd != 2 || family.CorrectMul != 2 {
+		t.Errorf("Got %d (3CosAdd) and %d (3CosMul) instead of 2 correct answers", family.CorrectAdd, family.CorrectMul)
+	}
+
+	if report.Total.Questions != 3 || report.Total.OOV != 1 {
+		t.Errorf("Got %v for the total", report.Total)
+	}
+	if acc := report.Sections[1].AccuracyAdd(); acc != 0 {
+		t.Errorf("Got accuracy %f instead of 0 for the fruit section", acc)
+	}
+}
+
+func TestEvaluateAnalogiesMalformed(t *testing.T) {
+	fmt.Println("TestEvaluateAnalogiesMalformed")
+	if _, err := EvaluateAnalogiesReader(strings.NewReader("man woman king queen\n"), analogyModel()); err == nil {
+		t.Errorf("Expected an error for a question without section")
+	}
+	if _, err := EvaluateAnalogiesReader(strings.NewReader(": s\nman woman king\n"), analogyModel()); err == nil {
+		t.Errorf("Expected an error for a question with three words")
+	}
+	if _, err := EvaluateAnalogies("does-not-exist.txt", analogyModel()); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestEvaluateAnalogiesCaseSensitive(t *testing.T) {
+	fmt.Println("TestEvaluateAnalogiesCaseSensitive")
+	// like GoogleNews vectors, the vocabulary keeps the case of the words
+	lower := analogyModel()
+	words := []string{"King", "Queen", "Man", "Woman", "Apple", "Pear"}
+	model := newLoadedModel(words, lower.Syn0.RawMatrix().Data, lower.VecDim)
+	model.Tokenizer = &UnicodeTokenizer{}
+
+	report, err := EvaluateAnalogiesReader(strings.NewReader(": family\nMan Woman King Queen\n"), model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total.Questions != 1 || report.Total.OOV != 0 || report.Total.CorrectAdd != 1 {
+		t.Errorf("Got %v for a case sensitive model", report.Total)
+	}
+
+	pairs, err := EvaluateWordPairsReader(strings.NewReader("King\tQueen\t8.0\nApple\tPear\t9.0\nMan\tApple\t0.5\n"), model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairs.Pairs != 3 || pairs.OOV != 0 {
+		t.Errorf("Got %v for a case sensitive model", pairs)
+	}
+}
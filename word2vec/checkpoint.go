@@ -0,0 +1,195 @@
+package word2vec
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// checkpointVersion is increased whenever the checkpoint layout changes
+const checkpointVersion = 1
+
+// checkpoint is the gob encoded training state written by SaveCheckpoint.
+// Unlike Save it keeps everything Train needs to continue: counts, output layers,
+// hyperparameters, progress and the seed the worker random sources are derived from.
+type checkpoint struct {
+	Version int
+	Config
+	Tokenizer *UnicodeTokenizer // nil for DefaultTokenizer
+
+	TotalCorpusCount int
+	Epoch            int
+	WordCount        int64
+
+	Words     []string
+	Counts    []int
+	SampleInt []int
+	Updated   []int64
+
+	Syn0    []float64
+	Syn1    []float64
+	Syn1Neg []float64
+
+	Syn0Vocab  []float64
+	Syn0Ngrams []float64
+}
+
+// SaveCheckpoint writes the full training state of the model to path.
+// The file is written next to path and renamed, so a crash never leaves a truncated checkpoint.
+// Resuming works at epoch granularity: the position within an epoch and the state of the worker
+// random sources are not saved, a resumed model trains the epochs after model.Epoch from the start.
+func SaveCheckpoint(model *Model, path string) error {
+	c := checkpoint{
+		Version:          checkpointVersion,
+		Config:           ModelConfig(model),
+		Tokenizer:        savedTokenizer(model.Tokenizer),
+		TotalCorpusCount: model.TotalCorpusCount,
+		Epoch:            model.Epoch,
+		WordCount:        model.WordCount,
+		Syn0:             denseData(model.Syn0),
+		Syn1:             denseData(model.Syn1),
+		Syn1Neg:          denseData(model.Syn1Neg),
+		Syn0Vocab:        denseData(model.Syn0Vocab),
+		Syn0Ngrams:       denseData(model.Syn0Ngrams),
+	}
+
+	for _, phraseObj := range model.Vocab {
+		c.Words = append(c.Words, phraseObj.Literal)
+		c.Counts = append(c.Counts, phraseObj.Count)
+		c.SampleInt = append(c.SampleInt, phraseObj.SampleInt)
+		c.Updated = append(c.Updated, phraseObj.Updated)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = gob.NewEncoder(w).Encode(&c)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// LoadCheckpoint restores a model written by SaveCheckpoint.
+// Passing the model to Train (or TrainCorpus) continues with the next epoch;
+// to train a finished model on new data raise model.Epochs or reset model.Epoch and model.WordCount.
+func LoadCheckpoint(path string) (*Model, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var c checkpoint
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&c); err != nil {
+		return nil, err
+	}
+
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("word2vec: unsupported checkpoint version %d", c.Version)
+	}
+
+	size := len(c.Words)
+	if len(c.Counts) != size || len(c.SampleInt) != size || len(c.Updated) != size {
+		return nil, fmt.Errorf("word2vec: checkpoint has %d words but %d counts, %d sample thresholds and %d update counts",
+			size, len(c.Counts), len(c.SampleInt), len(c.Updated))
+	}
+
+	if err := c.Config.Validate(); err != nil {
+		return nil, err
+	}
+
+	// the layers the configuration trains must be present, all with one row per word
+	if size > 0 && (c.Syn0 == nil || c.HS && c.Syn1 == nil || c.Negative > 0 && c.Syn1Neg == nil || c.Buckets > 0 && c.Syn0Vocab == nil) {
+		return nil, fmt.Errorf("word2vec: checkpoint misses a layer of its configuration")
+	}
+	if (c.Syn0Vocab != nil) != (c.Syn0Ngrams != nil) {
+		return nil, fmt.Errorf("word2vec: checkpoint has only one of the subword layers")
+	}
+	for _, layer := range [][]float64{c.Syn0, c.Syn1, c.Syn1Neg, c.Syn0Vocab} {
+		if layer != nil && len(layer) != size*c.VecDim {
+			return nil, fmt.Errorf("word2vec: checkpoint layer has %d values, expected %d", len(layer), size*c.VecDim)
+		}
+	}
+	if c.Syn0Ngrams != nil && len(c.Syn0Ngrams) != c.Buckets*c.VecDim {
+		return nil, fmt.Errorf("word2vec: checkpoint n-gram layer has %d values, expected %d", len(c.Syn0Ngrams), c.Buckets*c.VecDim)
+	}
+
+	model := newModelConfig(c.Config)
+	model.Tokenizer = loadedTokenizer(c.Tokenizer)
+	model.TotalCorpusCount = c.TotalCorpusCount
+	model.Epoch = c.Epoch
+	model.WordCount = c.WordCount
+
+	if size == 0 {
+		return model, nil
+	}
+
+	model.Syn0 = mat64.NewDense(size, c.VecDim, c.Syn0)
+	if c.Syn1 != nil {
+		model.Syn1 = mat64.NewDense(size, c.VecDim, c.Syn1)
+	}
+	if c.Syn1Neg != nil {
+		model.Syn1Neg = mat64.NewDense(size, c.VecDim, c.Syn1Neg)
+	}
+	if c.Syn0Vocab != nil {
+		model.Syn0Vocab = mat64.NewDense(size, c.VecDim, c.Syn0Vocab)
+		model.Syn0Ngrams = mat64.NewDense(c.Buckets, c.VecDim, c.Syn0Ngrams)
+	}
+
+	for index, word := range c.Words {
+		phrase := &Phrase{
+			Literal:   word,
+			Count:     c.Counts[index],
+			Id:        index,
+			SampleInt: c.SampleInt[index],
+			Updated:   c.Updated[index],
+			Vector:    model.Syn0.RowView(index),
+		}
+		model.Vocab = append(model.Vocab, phrase)
+		model.RawVocab[word] = phrase
+		model.Word2Index[word] = index
+		if model.Buckets > 0 {
+			phrase.Ngrams = ngramBuckets(word, model)
+		}
+	}
+
+	if model.HS {
+		model = createBinaryTree(model)
+	}
+	if model.Negative > 0 {
+		model = makeCumTable(model)
+	}
+
+	return InitSims(model), nil
+}
+
+// denseData copies the elements of m in row-major order, nil for a nil matrix
+func denseData(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+
+	r, c := m.Dims()
+	data := make([]float64, 0, r*c)
+	for i := 0; i < r; i++ {
+		data = append(data, m.RawRowView(i)...)
+	}
+	return data
+}
@@ -0,0 +1,117 @@
+package word2vec
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	fmt.Println("TestCheckpointResume")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	model := testModel(t, testConfig(1), tinySentences)
+	model.CheckpointPath = filepath.Join(dir, "checkpoint.gob")
+	model = Train(tinySentences, model)
+
+	restored, err := LoadCheckpoint(model.CheckpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Epoch != 1 || restored.WordCount != model.WordCount {
+		t.Errorf("Got epoch %d and word count %d instead of 1 and %d", restored.Epoch, restored.WordCount, model.WordCount)
+	}
+	if !mat64.Equal(restored.Syn0, model.Syn0) || !mat64.Equal(restored.Syn1, model.Syn1) {
+		t.Errorf("Weights differ after loading the checkpoint")
+	}
+	if restored.Vocab[restored.Word2Index["want"]].Count != 2 {
+		t.Errorf("Counts were not restored")
+	}
+
+	// resuming the same checkpoint twice must give the same second epoch
+	results := make([]*Model, 0)
+	for i := 0; i < 2; i++ {
+		resumed, err := LoadCheckpoint(model.CheckpointPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resumed.CheckpointPath = ""
+		resumed.Epochs = 2
+		resumed = Train(tinySentences, resumed)
+
+		if resumed.Epoch != 2 {
+			t.Errorf("Got epoch %d instead of 2 after resuming", resumed.Epoch)
+		}
+		results = append(results, resumed)
+	}
+
+	if mat64.Equal(results[0].Syn0, restored.Syn0) {
+		t.Errorf("Resumed training did not update Syn0")
+	}
+	if !mat64.Equal(results[0].Syn0, results[1].Syn0) {
+		t.Errorf("Resuming the same checkpoint gave different weights")
+	}
+}
+
+func TestLoadCheckpointCorrupt(t *testing.T) {
+	fmt.Println("TestLoadCheckpointCorrupt")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	model := Train(tinySentences, testModel(t, testConfig(1), tinySentences))
+	path := filepath.Join(dir, "checkpoint.gob")
+	if err := SaveCheckpoint(model, path); err != nil {
+		t.Fatal(err)
+	}
+
+	corruptions := map[string]func(c *checkpoint){
+		"short counts":      func(c *checkpoint) { c.Counts = c.Counts[1:] },
+		"short updates":     func(c *checkpoint) { c.Updated = nil },
+		"missing Syn1":      func(c *checkpoint) { c.Syn1 = nil },
+		"short Syn0":        func(c *checkpoint) { c.Syn0 = c.Syn0[1:] },
+		"zero dimension":    func(c *checkpoint) { c.VecDim = 0 },
+		"one subword layer": func(c *checkpoint) { c.Syn0Ngrams = []float64{0} },
+		"negative buckets":  func(c *checkpoint) { c.Buckets = -1 },
+	}
+	for name, corrupt := range corruptions {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c checkpoint
+		err = gob.NewDecoder(f).Decode(&c)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		corrupt(&c)
+		corruptPath := filepath.Join(dir, "corrupt.gob")
+		f, err = os.Create(corruptPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = gob.NewEncoder(f).Encode(&c)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadCheckpoint(corruptPath); err == nil {
+			t.Errorf("Expected an error for a checkpoint with %s", name)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package word2vec
+
+import (
+	"bufio"
+	"math"
+)
+
+// Config holds the hyperparameters of a model, start from DefaultConfig and pass it to InitModelConfig.
+// It is stored with models written by Save and SaveCheckpoint.
+type Config struct {
+	Epochs       int
+	MinCount     int // words seen less often are dropped from the vocabulary
+	MaxVocabSize int // keep only the most frequent words, 0 is unlimited
+	VecDim       int
+	Window       int  // maximum distance of context words
+	ShrinkWindow bool // like the C tool, draw the window of every word from [1, Window]
+	Architecture Architecture
+	CBOWMean     bool
+	HS           bool
+	Negative     int
+	NSExponent   float64
+	Sample       float64 // threshold for downsampling frequent words, 0 disables downsampling
+	Alpha        float64
+	MinAlpha     float64
+	Workers      int   // training goroutines, more than 1 is faster but the order of the updates varies
+	Seed         int64 // runs with the same Seed give the same vectors only with a single worker
+	MinN         int
+	MaxN         int
+	Buckets      int
+}
+
+// headerConfig is the json config field of the Save header, the tokenizer options are
+// missing in files of models with DefaultTokenizer and files written before they were stored
+type headerConfig struct {
+	Config
+	Tokenizer *UnicodeTokenizer `json:",omitempty"`
+}
+
+// DefaultConfig returns the parameters of InitModel, epochs, min count and dimension as in gensim.
+// Unlike gensim it trains with a single worker, so that a seeded run is reproducible.
+func DefaultConfig() Config {
+	return Config{
+		Epochs:       5,
+		MinCount:     5,
+		VecDim:       100,
+		Window:       7,
+		ShrinkWindow: true,
+		Architecture: SkipGram,
+		CBOWMean:     true,
+		Negative:     5,
+		NSExponent:   0.75,
+		Sample:       0.001,
+		Alpha:        0.025,
+		MinAlpha:     0.0001,
+		Workers:      1,
+		Seed:         7456393,
+		MinN:         3,
+		MaxN:         6,
+	}
+}
+
+// Validate returns a ConfigError for the first parameter out of range
+func (c Config) Validate() error {
+	switch {
+	case c.Epochs < 1:
+		return &ConfigError{"Epochs", c.Epochs, "must be at least 1"}
+	case c.MinCount < 0:
+		return &ConfigError{"MinCount", c.MinCount, "must not be negative"}
+	case c.MaxVocabSize < 0:
+		return &ConfigError{"MaxVocabSize", c.MaxVocabSize, "must not be negative"}
+	case c.VecDim < 1:
+		return &ConfigError{"VecDim", c.VecDim, "must be at least 1"}
+	case c.Window < 1:
+		return &ConfigError{"Window", c.Window, "must be at least 1"}
+	case c.Architecture != SkipGram && c.Architecture != CBOW:
+		return &ConfigError{"Architecture", c.Architecture, "must be SkipGram or CBOW"}
+	case c.Negative < 0:
+		return &ConfigError{"Negative", c.Negative, "must not be negative"}
+	case !c.HS && c.Negative == 0:
+		return &ConfigError{"Negative", c.Negative, "must be positive without hierarchical softmax"}
+	case math.IsNaN(c.NSExponent) || math.IsInf(c.NSExponent, 0):
+		return &ConfigError{"NSExponent", c.NSExponent, "must be finite"}
+	case !(c.Sample >= 0):
+		return &ConfigError{"Sample", c.Sample, "must not be negative"}
+	case !(c.Alpha > 0):
+		return &ConfigError{"Alpha", c.Alpha, "must be positive"}
+	case !(c.MinAlpha >= 0 && c.MinAlpha <= c.Alpha):
+		return &ConfigError{"MinAlpha", c.MinAlpha, "must be between 0 and Alpha"}
+	case c.Workers < 1:
+		return &ConfigError{"Workers", c.Workers, "must be at least 1"}
+	case c.Buckets < 0:
+		return &ConfigError{"Buckets", c.Buckets, "must not be negative"}
+	case c.Buckets > 0 && c.MinN < 1:
+		return &ConfigError{"MinN", c.MinN, "must be at least 1 with subwords"}
+	case c.Buckets > 0 && c.MaxN < c.MinN:
+		return &ConfigError{"MaxN", c.MaxN, "must not be less than MinN with subwords"}
+	}
+	return nil
+}
+
+// InitModelConfig validates config and initializes the model, it expects stopwords.txt in the current directory
+func InitModelConfig(config Config) (*Model, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	f, err := openFile("stopwords.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	stopwords := make([]string, 0)
+
+	s := bufio.NewScanner(f)
+	s.Split(bufio.ScanLines)
+
+	for s.Scan() {
+		stopwords = append(stopwords, s.Text())
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	model := newModelConfig(config)
+	model.stopwords = stopwords
+
+	return model, nil
+}
+
+// ModelConfig returns the current hyperparameters of the model
+func ModelConfig(model *Model) Config {
+	return Config{
+		Epochs:       model.Epochs,
+		MinCount:     model.MinCount,
+		MaxVocabSize: model.MaxVocabSize,
+		VecDim:       model.VecDim,
+		Window:       model.Window,
+		ShrinkWindow: model.ShrinkWindow,
+		Architecture: model.Architecture,
+		CBOWMean:     model.CBOWMean,
+		HS:           model.HS,
+		Negative:     model.Negative,
+		NSExponent:   model.NSExponent,
+		Sample:       model.Sample,
+		Alpha:        model.Alpha,
+		MinAlpha:     model.MinAlpha,
+		Workers:      model.Workers,
+		Seed:         model.Seed,
+		MinN:         model.MinN,
+		MaxN:         model.MaxN,
+		Buckets:      model.Buckets,
+	}
+}
+
+// applyConfig sets the hyperparameters of the model, the vocabulary and weights are not changed
+func applyConfig(model *Model, config Config) *Model {
+	model.Epochs = config.Epochs
+	model.MinCount = config.MinCount
+	model.MaxVocabSize = config.MaxVocabSize
+	model.VecDim = config.VecDim
+	model.Window = config.Window
+	model.ShrinkWindow = config.ShrinkWindow
+	model.Architecture = config.Architecture
+	model.CBOWMean = config.CBOWMean
+	model.HS = config.HS
+	model.Negative = config.Negative
+	model.NSExponent = config.NSExponent
+	model.Sample = config.Sample
+	model.Alpha = config.Alpha
+	model.MinAlpha = config.MinAlpha
+	model.Workers = config.Workers
+	model.Seed = config.Seed
+	model.MinN = config.MinN
+	model.MaxN = config.MaxN
+	model.Buckets = config.Buckets
+	return model
+}
@@ -0,0 +1,158 @@
+package word2vec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	fmt.Println("TestConfigValidate")
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("Default config is invalid: %v", err)
+	}
+
+	cases := []struct {
+		field  string
+		modify func(c *Config)
+	}{
+		{"Epochs", func(c *Config) { c.Epochs = 0 }},
+		{"MaxVocabSize", func(c *Config) { c.MaxVocabSize = -1 }},
+		{"VecDim", func(c *Config) { c.VecDim = 0 }},
+		{"Window", func(c *Config) { c.Window = 0 }},
+		{"Architecture", func(c *Config) { c.Architecture = 2 }},
+		{"Negative", func(c *Config) { c.Negative = 0 }},
+		{"NSExponent", func(c *Config) { c.NSExponent = math.NaN() }},
+		{"Sample", func(c *Config) { c.Sample = -0.1 }},
+		{"Alpha", func(c *Config) { c.Alpha = 0 }},
+		{"MinAlpha", func(c *Config) { c.MinAlpha = 0.5 }},
+		{"Workers", func(c *Config) { c.Workers = 0 }},
+		{"MaxN", func(c *Config) { c.Buckets, c.MaxN = 100, 2 }},
+	}
+
+	for _, tc := range cases {
+		config := DefaultConfig()
+		tc.modify(&config)
+
+		err := config.Validate()
+		if configErr, ok := err.(*ConfigError); !ok || configErr.Field != tc.field {
+			t.Errorf("Got %v instead of a ConfigError for %s", err, tc.field)
+		}
+	}
+
+	// hierarchical softmax alone is a valid output layer
+	config := DefaultConfig()
+	config.HS, config.Negative = true, 0
+	if err := config.Validate(); err != nil {
+		t.Errorf("Got %v for hierarchical softmax without negative sampling", err)
+	}
+
+	config.Window = -1
+	if _, err := InitModelConfig(config); err == nil {
+		t.Errorf("InitModelConfig accepted an invalid config")
+	}
+}
+
+func TestMaxVocabSize(t *testing.T) {
+	fmt.Println("TestMaxVocabSize")
+	config := DefaultConfig()
+	config.MinCount = 1
+	config.MaxVocabSize = 2
+	model := newModelConfig(config)
+
+	model = BuildVocab(tinySentences, model)
+	model = ScaleVocab(model)
+
+	// "a" and "want" are the only words seen twice
+	if len(model.Vocab) != 2 || model.Vocab[0].Literal != "a" || model.Vocab[1].Literal != "want" {
+		t.Errorf("Got %d words instead of a and want", len(model.Vocab))
+	}
+	if model.TotalCorpusCount != 4 {
+		t.Errorf("Got corpus count %d instead of 4", model.TotalCorpusCount)
+	}
+}
+
+func TestSaveLoadConfig(t *testing.T) {
+	fmt.Println("TestSaveLoadConfig")
+	config := DefaultConfig()
+	config.MinCount = 1
+	config.VecDim = 5
+	config.Window = 3
+	config.ShrinkWindow = false
+	config.Architecture = CBOW
+	config.MinAlpha = 0.001
+	config.Seed = 42
+
+	model, err := InitModelConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = BuildVocab(tinySentences, model)
+	model = ScaleVocab(model)
+	model = resetWeights(model)
+	// spaces in the options must not split the header
+	model.Tokenizer = &UnicodeTokenizer{Lowercase: true, NumberToken: "<a\u00a0number>", URLToken: "<a link>"}
+
+	if b := reducedWindow(model, nil); b != 0 {
+		t.Errorf("Got reduced window %d without ShrinkWindow", b)
+	}
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "model.txt")
+	if err := Save(model, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ModelConfig(loaded) != config {
+		t.Errorf("Got config %+v instead of %+v", ModelConfig(loaded), config)
+	}
+	if !reflect.DeepEqual(loaded.Tokenizer, model.Tokenizer) {
+		t.Errorf("Got tokenizer %+v instead of %+v", loaded.Tokenizer, model.Tokenizer)
+	}
+
+	path = filepath.Join(dir, "checkpoint.gob")
+	if err := SaveCheckpoint(model, path); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ModelConfig(restored) != config {
+		t.Errorf("Got checkpoint config %+v instead of %+v", ModelConfig(restored), config)
+	}
+	if !reflect.DeepEqual(restored.Tokenizer, model.Tokenizer) {
+		t.Errorf("Got checkpoint tokenizer %+v instead of %+v", restored.Tokenizer, model.Tokenizer)
+	}
+
+	// headers without config load with the defaults
+	legacy := filepath.Join(dir, "legacy.txt")
+	if err := ioutil.WriteFile(legacy, []byte("2 1\nword\n0.5 0.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := Load(legacy); err != nil || loaded.Window != DefaultConfig().Window {
+		t.Errorf("Could not load a model without config: %v", err)
+	}
+
+	broken := filepath.Join(dir, "broken.txt")
+	if err := ioutil.WriteFile(broken, []byte("2 1 {\"VecDim\":3}\nword\n0.5 0.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(broken); err == nil {
+		t.Errorf("Expected HeaderError for a config with another dimension")
+	} else if _, ok := err.(*HeaderError); !ok {
+		t.Errorf("Got %T instead of *HeaderError", err)
+	}
+}
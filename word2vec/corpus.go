@@ -0,0 +1,118 @@
+package word2vec
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Corpus is a source of tokenized sentences that can be iterated more than once,
+// BuildVocabCorpus makes one pass over it and TrainCorpus one pass per epoch.
+type Corpus interface {
+	// Iterate calls fn for every sentence and stops at the first error
+	Iterate(fn func(sentence []string) error) error
+}
+
+// SliceCorpus is an in-memory corpus
+type SliceCorpus [][]string
+
+// Iterate calls fn for every sentence
+func (c SliceCorpus) Iterate(fn func(sentence []string) error) error {
+	for _, sentence := range c {
+		if err := fn(sentence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LineCorpus reads a text file with one whitespace separated sentence per line
+type LineCorpus struct {
+	Path string
+}
+
+// Iterate calls fn for every line of the file
+func (c LineCorpus) Iterate(fn func(sentence []string) error) error {
+	f, err := openFile(c.Path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return iterateLines(f, fn)
+}
+
+// GzipCorpus reads a gzip compressed text file with one sentence per line
+type GzipCorpus struct {
+	Path string
+}
+
+// Iterate calls fn for every line of the decompressed file
+func (c GzipCorpus) Iterate(fn func(sentence []string) error) error {
+	f, err := openFile(c.Path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	defer gz.Close()
+
+	return iterateLines(gz, fn)
+}
+
+// DirCorpus reads all files of a directory in lexical order.
+// Files ending in .gz are read as GzipCorpus, all others as LineCorpus.
+type DirCorpus struct {
+	Path string
+}
+
+// Iterate calls fn for every line of every file in the directory
+func (c DirCorpus) Iterate(fn func(sentence []string) error) error {
+	infos, err := ioutil.ReadDir(c.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+
+		var corpus Corpus = LineCorpus{Path: filepath.Join(c.Path, info.Name())}
+		if strings.HasSuffix(info.Name(), ".gz") {
+			corpus = GzipCorpus{Path: filepath.Join(c.Path, info.Name())}
+		}
+
+		if err := corpus.Iterate(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func iterateLines(r io.Reader, fn func(sentence []string) error) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	s.Split(bufio.ScanLines)
+
+	for s.Scan() {
+		sentence := strings.Fields(s.Text())
+		if len(sentence) == 0 {
+			continue
+		}
+		if err := fn(sentence); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
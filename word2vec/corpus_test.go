@@ -0,0 +1,101 @@
+package word2vec
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestCorpus(t *testing.T, dir string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("I want a dog\n\nYou want a cat\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, "b.txt.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte("a dog and a cat\n"))
+	gz.Close()
+	f.Close()
+}
+
+func countSentences(t *testing.T, corpus Corpus) int {
+	sentences := 0
+	err := corpus.Iterate(func(sentence []string) error {
+		sentences++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sentences
+}
+
+func TestCorpusReaders(t *testing.T) {
+	fmt.Println("TestCorpusReaders")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestCorpus(t, dir)
+
+	if n := countSentences(t, LineCorpus{Path: filepath.Join(dir, "a.txt")}); n != 2 {
+		t.Errorf("Got %d instead of 2 sentences from LineCorpus", n)
+	}
+	if n := countSentences(t, GzipCorpus{Path: filepath.Join(dir, "b.txt.gz")}); n != 1 {
+		t.Errorf("Got %d instead of 1 sentence from GzipCorpus", n)
+	}
+
+	// a DirCorpus must be re-iterable
+	corpus := DirCorpus{Path: dir}
+	for pass := 0; pass < 2; pass++ {
+		if n := countSentences(t, corpus); n != 3 {
+			t.Errorf("Got %d instead of 3 sentences from DirCorpus in pass %d", n, pass)
+		}
+	}
+
+	err = LineCorpus{Path: filepath.Join(dir, "missing.txt")}.Iterate(func(sentence []string) error { return nil })
+	if _, ok := err.(*MissingFileError); !ok {
+		t.Errorf("Got %v instead of *MissingFileError", err)
+	}
+}
+
+func TestBuildVocabTrainCorpus(t *testing.T) {
+	fmt.Println("TestBuildVocabTrainCorpus")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestCorpus(t, dir)
+
+	model, err := InitModelConfig(testConfig(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corpus := DirCorpus{Path: dir}
+	if model, err = BuildVocabCorpus(corpus, model); err != nil {
+		t.Fatal(err)
+	}
+	if len(model.RawVocab) != 7 {
+		t.Errorf("Got %d instead of 7 words", len(model.RawVocab))
+	}
+	if model.RawVocab["a"].Count != 4 {
+		t.Errorf("Got count %d instead of 4 for a", model.RawVocab["a"].Count)
+	}
+
+	model = ScaleVocab(model)
+	model = FinalizeVocab(model)
+	if _, err := TrainCorpus(corpus, model); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,274 @@
+package word2vec
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Paragraph vectors after Le & Mikolov: https://arxiv.org/abs/1405.4053
+// The word vocabulary, Huffman tree, CumTable and output layers (Syn1, Syn1Neg) are the
+// ones of the wrapped word2vec Model, documents only add one vector per tag.
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/doc2vec.py
+
+type DocArchitecture int
+
+const (
+	PVDM   DocArchitecture = iota // distributed memory: document and context words predict the center word
+	PVDBOW                        // distributed bag of words: the document predicts each of its words
+)
+
+// TaggedDocument is a document with one or more tags, a tag shared by several documents gets one vector
+type TaggedDocument struct {
+	Words []string
+	Tags  []string
+}
+
+type DocModel struct {
+	Model        *Model // word vectors, vocabulary and hyperparameters
+	Architecture DocArchitecture
+	DBOWWords    bool // PV-DBOW: also train word vectors with skip-gram
+	InferEpochs  int  // epochs of InferVector, 0 uses Model.Epochs
+	Tags         []string
+	Tag2Index    map[string]int
+	DocVecs      *mat64.Dense // size: tags * vector_dimension
+}
+
+// InitDocModel wraps a model created by InitModel, its Epochs, Window, HS, Negative etc. are used for documents
+func InitDocModel(model *Model, architecture DocArchitecture) *DocModel {
+	return &DocModel{
+		Model:        model,
+		Architecture: architecture,
+		Tags:         make([]string, 0),
+		Tag2Index:    make(map[string]int),
+	}
+}
+
+// BuildDocVocab builds, scales and finalizes the word vocabulary of the documents
+// and initializes one vector per tag
+func BuildDocVocab(docs []TaggedDocument, dm *DocModel) *DocModel {
+	sentences := make([][]string, 0, len(docs))
+	for _, doc := range docs {
+		sentences = append(sentences, doc.Words)
+
+		for _, tag := range doc.Tags {
+			if _, ok := dm.Tag2Index[tag]; !ok {
+				dm.Tag2Index[tag] = len(dm.Tags)
+				dm.Tags = append(dm.Tags, tag)
+			}
+		}
+	}
+
+	dm.Model = BuildVocab(sentences, dm.Model)
+	dm.Model = ScaleVocab(dm.Model)
+	dm.Model = FinalizeVocab(dm.Model)
+
+	if len(dm.Tags) == 0 {
+		return dm
+	}
+
+	// gensim: doc vectors are seeded like word vectors, by seed and tag
+	dm.DocVecs = mat64.NewDense(len(dm.Tags), dm.Model.VecDim, nil)
+	for index, tag := range dm.Tags {
+		dm.DocVecs.SetRow(index, seededVector(dm.Model, "doc "+tag))
+	}
+
+	return dm
+}
+
+// TrainDocs trains document and word vectors for the remaining epochs of dm.Model,
+// documents are split across dm.Model.Workers goroutines like sentences in TrainCorpus
+func TrainDocs(docs []TaggedDocument, dm *DocModel) *DocModel {
+	model := dm.Model
+	workers := model.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	progress := newProgressReporter(model)
+
+	for epoch := model.Epoch + 1; epoch <= model.Epochs; epoch++ {
+		jobs := make(chan TaggedDocument, 2*workers)
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+
+				r := rand.New(rand.NewSource(model.Seed + int64(epoch*workers+w)))
+				for doc := range jobs {
+					loss := trainDocument(dm, doc, &model.WordCount, r)
+					progress.add(epoch, loss, atomic.LoadInt64(&model.WordCount))
+				}
+			}(w)
+		}
+
+		for _, doc := range docs {
+			jobs <- doc
+		}
+		close(jobs)
+		wg.Wait()
+
+		model.Epoch = epoch
+		progress.epochEnd(epoch)
+	}
+
+	if model.Buckets > 0 {
+		model = adjustVectors(model)
+	}
+	dm.Model = InitSims(model)
+	return dm
+}
+
+func trainDocument(dm *DocModel, doc TaggedDocument, wordCount *int64, r *rand.Rand) float64 {
+	words, _ := sentencePhrases(dm.Model, doc.Words, wordCount, r)
+
+	docVecs := make([]*mat64.Vector, 0, len(doc.Tags))
+	for _, tag := range doc.Tags {
+		if idx, ok := dm.Tag2Index[tag]; ok {
+			docVecs = append(docVecs, dm.DocVecs.RowView(idx))
+		}
+	}
+
+	alpha := currentAlpha(dm.Model, atomic.LoadInt64(wordCount))
+	return trainDocWords(dm, words, docVecs, alpha, true, r)
+}
+
+// trainDocWords trains the document vectors docVecs on words and returns the loss,
+// with learnWords false the word vectors and output layers are frozen (see InferVector)
+func trainDocWords(dm *DocModel, words []*Phrase, docVecs []*mat64.Vector, alpha float64, learnWords bool, r *rand.Rand) float64 {
+	model := dm.Model
+	loss := 0.0
+
+	if dm.Architecture == PVDBOW {
+		for _, docVec := range docVecs {
+			for _, word := range words {
+				neu1e := mat64.NewVector(model.VecDim, nil)
+				loss += trainOutput(model, word, docVec, neu1e, alpha, learnWords, r)
+				docVec.AddVec(docVec, neu1e)
+			}
+		}
+
+		if dm.DBOWWords && learnWords {
+			for sentencePosition := range words {
+				b := reducedWindow(model, r)
+				loss += trainSG(model, words, sentencePosition, b, alpha, r)
+			}
+		}
+		return loss
+	}
+
+	// PV-DM: like trainCBOW with the document vectors added to the context
+	for sentencePosition, word := range words {
+		b := reducedWindow(model, r)
+
+		neu1 := mat64.NewVector(model.VecDim, nil)
+		for _, docVec := range docVecs {
+			neu1.AddVec(neu1, docVec)
+		}
+
+		context := make([]*Phrase, 0)
+		for a := b; a < model.Window*2+1-b; a++ {
+			c := sentencePosition - model.Window + a
+
+			if c < 0 || c >= len(words) || c == sentencePosition {
+				continue
+			}
+
+			lastWord := words[c]
+			neu1.AddVec(neu1, inputVector(model, lastWord))
+			context = append(context, lastWord)
+		}
+
+		count := len(docVecs) + len(context)
+		if count == 0 {
+			continue
+		}
+
+		if model.CBOWMean {
+			neu1.ScaleVec(1.0/float64(count), neu1)
+		}
+
+		neu1e := mat64.NewVector(model.VecDim, nil)
+		loss += trainOutput(model, word, neu1, neu1e, alpha, learnWords, r)
+
+		if !model.CBOWMean {
+			neu1e.ScaleVec(1.0/float64(count), neu1e)
+		}
+
+		for _, docVec := range docVecs {
+			docVec.AddVec(docVec, neu1e)
+		}
+
+		if learnWords {
+			for _, lastWord := range context {
+				updateInput(model, lastWord, neu1e)
+				atomic.AddInt64(&lastWord.Updated, 1)
+			}
+		}
+	}
+
+	return loss
+}
+
+// InferVector trains a vector for an unseen document with frozen word vectors and output layers,
+// the learning rate decays linearly from Alpha to MinAlpha over InferEpochs. The model is not changed.
+func InferVector(words []string, dm *DocModel) *mat64.Vector {
+	model := dm.Model
+
+	epochs := dm.InferEpochs
+	if epochs <= 0 {
+		epochs = model.Epochs
+	}
+
+	// gensim: the start vector and the negative draws are seeded by the document text
+	r := rand.New(rand.NewSource(model.Seed + int64(hash(strings.Join(words, " ")))))
+	vec := mat64.NewVector(model.VecDim, nil)
+	for i := 0; i < model.VecDim; i++ {
+		vec.SetVec(i, (r.Float64()-0.5)/float64(model.VecDim))
+	}
+
+	var wordCount int64
+	for epoch := 0; epoch < epochs; epoch++ {
+		alpha := model.Alpha - (model.Alpha-model.MinAlpha)*float64(epoch)/float64(epochs)
+
+		phrases, _ := sentencePhrases(model, words, &wordCount, r)
+		trainDocWords(dm, phrases, []*mat64.Vector{vec}, alpha, false, r)
+	}
+
+	return vec
+}
+
+// DocVector returns the trained vector of a document tag
+func DocVector(tag string, dm *DocModel) (*mat64.Vector, error) {
+	idx, ok := dm.Tag2Index[tag]
+	if !ok {
+		return nil, &UnknownTagError{Tag: tag}
+	}
+	return dm.DocVecs.RowView(idx), nil
+}
+
+// MostSimilarDocs returns the top tags by cosine similarity of their document vectors to vec
+func MostSimilarDocs(vec *mat64.Vector, top int, dm *DocModel) []SimPair {
+	if dm.DocVecs == nil {
+		return make([]SimPair, 0)
+	}
+
+	sims := make([]float64, len(dm.Tags))
+	vecNorm := mat64.Norm(vec, 2)
+	for index := range dm.Tags {
+		docVec := dm.DocVecs.RowView(index)
+		if length := mat64.Norm(docVec, 2) * vecNorm; length > 0 {
+			sims[index] = mat64.Dot(vec, docVec) / length
+		} else {
+			sims[index] = math.Inf(-1)
+		}
+	}
+
+	return topSimilarLabels(sims, top, nil, func(idx int) string { return dm.Tags[idx] })
+}
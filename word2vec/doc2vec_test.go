@@ -0,0 +1,125 @@
+package word2vec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+var taggedDocs = []TaggedDocument{
+	{Words: []string{"I", "want", "a", "dog"}, Tags: []string{"dog"}},
+	{Words: []string{"You", "want", "a", "cat"}, Tags: []string{"cat"}},
+	{Words: []string{"the", "dog", "chased", "the", "cat"}, Tags: []string{"dog", "chase"}},
+}
+
+func docModel(t *testing.T, architecture DocArchitecture) *DocModel {
+	model, err := InitModelConfig(testConfig(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return BuildDocVocab(taggedDocs, InitDocModel(model, architecture))
+}
+
+func TestBuildDocVocab(t *testing.T) {
+	fmt.Println("TestBuildDocVocab")
+	dm := docModel(t, PVDM)
+
+	if len(dm.Tags) != 3 {
+		t.Errorf("Got %d instead of 3 tags", len(dm.Tags))
+	}
+	if r, _ := dm.DocVecs.Dims(); r != 3 {
+		t.Errorf("Got %d instead of 3 document vectors", r)
+	}
+	if _, ok := dm.Model.Word2Index["chased"]; !ok {
+		t.Errorf("Document words are missing from the vocabulary")
+	}
+}
+
+func TestTrainDocs(t *testing.T) {
+	fmt.Println("TestTrainDocs")
+	for _, architecture := range []DocArchitecture{PVDM, PVDBOW} {
+		dm := docModel(t, architecture)
+		dm.DBOWWords = true
+
+		docsBefore := mat64.DenseCopyOf(dm.DocVecs)
+		wordsBefore := mat64.DenseCopyOf(dm.Model.Syn0)
+		dm = TrainDocs(taggedDocs, dm)
+
+		if mat64.Equal(docsBefore, dm.DocVecs) {
+			t.Errorf("Architecture %d did not update the document vectors", architecture)
+		}
+		if mat64.Equal(wordsBefore, dm.Model.Syn0) {
+			t.Errorf("Architecture %d did not update the word vectors", architecture)
+		}
+		if dm.Model.Epoch != dm.Model.Epochs {
+			t.Errorf("Got epoch %d instead of %d", dm.Model.Epoch, dm.Model.Epochs)
+		}
+
+		vec, err := DocVector("dog", dm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sims := MostSimilarDocs(vec, 1, dm)
+		if len(sims) != 1 || sims[0].Key != "dog" {
+			t.Errorf("Got %v instead of the document itself", sims)
+		}
+	}
+}
+
+func TestTrainDocsSubwords(t *testing.T) {
+	fmt.Println("TestTrainDocsSubwords")
+	config := testConfig(5)
+	config.Buckets = 1000
+	model, err := InitModelConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := BuildDocVocab(taggedDocs, InitDocModel(model, PVDM))
+
+	wordsBefore := mat64.DenseCopyOf(dm.Model.Syn0)
+	dm = TrainDocs(taggedDocs, dm)
+
+	// the word vectors are the mean of the trained word and n-gram vectors
+	if mat64.Equal(wordsBefore, dm.Model.Syn0) {
+		t.Errorf("Training with subwords did not update the word vectors")
+	}
+	dog := dm.Model.Vocab[dm.Model.Word2Index["dog"]]
+	if !mat64.Equal(dog.Vector, inputVector(dm.Model, dog)) {
+		t.Errorf("Vector of dog is not the mean of its word and n-gram vectors")
+	}
+}
+
+func TestInferVector(t *testing.T) {
+	fmt.Println("TestInferVector")
+	dm := TrainDocs(taggedDocs, docModel(t, PVDM))
+
+	syn0 := mat64.DenseCopyOf(dm.Model.Syn0)
+	syn1 := mat64.DenseCopyOf(dm.Model.Syn1)
+	docVecs := mat64.DenseCopyOf(dm.DocVecs)
+
+	unseen := []string{"a", "cat", "chased", "a", "dog"}
+	vec := InferVector(unseen, dm)
+
+	if vec.Len() != dm.Model.VecDim {
+		t.Errorf("Got vector of length %d instead of %d", vec.Len(), dm.Model.VecDim)
+	}
+	if !mat64.Equal(syn0, dm.Model.Syn0) || !mat64.Equal(syn1, dm.Model.Syn1) || !mat64.Equal(docVecs, dm.DocVecs) {
+		t.Errorf("InferVector changed the trained model")
+	}
+	if again := InferVector(unseen, dm); !mat64.Equal(vec, again) {
+		t.Errorf("Inferring the same document twice gave different vectors")
+	}
+}
+
+func TestDocVectorUnknownTag(t *testing.T) {
+	fmt.Println("TestDocVectorUnknownTag")
+	dm := docModel(t, PVDBOW)
+
+	if _, err := DocVector("bird", dm); err == nil {
+		t.Errorf("Expected UnknownTagError")
+	} else if _, ok := err.(*UnknownTagError); !ok {
+		t.Errorf("Got %T instead of *UnknownTagError", err)
+	}
+}
@@ -0,0 +1,62 @@
+package word2vec
+
+import "fmt"
+
+// MissingFileError is returned when a model, corpus or stopword file does not exist
+type MissingFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *MissingFileError) Error() string {
+	return fmt.Sprintf("word2vec: missing file %s: %v", e.Path, e.Err)
+}
+
+// HeaderError is returned when the header line of a model file cannot be parsed
+type HeaderError struct {
+	Header string
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("word2vec: malformed header %q", e.Header)
+}
+
+// DimensionError is returned when a vector does not have the dimension of the model
+type DimensionError struct {
+	Word     string
+	Expected int
+	Got      int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("word2vec: vector of %q has %d dimensions, expected %d", e.Word, e.Got, e.Expected)
+}
+
+// UnknownWordError is returned when a query word is not in the vocabulary
+type UnknownWordError struct {
+	Word string
+}
+
+func (e *UnknownWordError) Error() string {
+	return fmt.Sprintf("word2vec: word %q not in vocabulary", e.Word)
+}
+
+// UnknownTagError is returned when a document tag was not seen by BuildDocVocab
+type UnknownTagError struct {
+	Tag string
+}
+
+func (e *UnknownTagError) Error() string {
+	return fmt.Sprintf("word2vec: document tag %q not in vocabulary", e.Tag)
+}
+
+// ConfigError is returned by Config.Validate for a parameter out of range
+type ConfigError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("word2vec: invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
@@ -0,0 +1,282 @@
+package word2vec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Format selects the on-disk layout used by SaveFormat and LoadFormat
+type Format int
+
+const (
+	// Legacy is the format of Save/Load: a "dim size config" header followed by the word
+	// on one line and its vector on the next, config is the json encoded Config of the model
+	// with the options of its UnicodeTokenizer
+	Legacy Format = iota
+	// Text is the text format of the original C tool: a "size dim" header followed
+	// by one "word v1 v2 ..." line per word
+	Text
+	// Binary is the binary format of the original C tool (GoogleNews vectors etc.):
+	// a "size dim" header followed by "word " and dim little-endian float32 per word
+	Binary
+)
+
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/keyedvectors.py (save_word2vec_format)
+
+// SaveFormat writes the vectors of the model to path in the given format
+func SaveFormat(model *Model, path string, format Format) error {
+	if format == Legacy {
+		return Save(model, path)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if format == Binary {
+		err = writeBinary(w, model)
+	} else {
+		err = writeText(w, model)
+	}
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// LoadFormat reads a model saved in the given format
+func LoadFormat(modelPath string, format Format) (*Model, error) {
+	if format == Legacy {
+		return Load(modelPath)
+	}
+
+	f, err := openFile(modelPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+
+	var words []string
+	var data []float64
+	var dim int
+	if format == Binary {
+		words, data, dim, err = readBinary(r, info.Size())
+	} else {
+		words, data, dim, err = readText(r, info.Size())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newLoadedModel(words, data, dim), nil
+}
+
+func writeText(w *bufio.Writer, model *Model) error {
+	if _, err := fmt.Fprintf(w, "%d %d\n", len(model.Vocab), model.VecDim); err != nil {
+		return err
+	}
+
+	for _, voc := range model.Vocab {
+		w.WriteString(voc.Literal)
+		for _, elem := range voc.Vector.RawVector().Data {
+			w.WriteString(" " + strconv.FormatFloat(elem, 'f', 6, 64))
+		}
+		if _, err := w.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBinary(w *bufio.Writer, model *Model) error {
+	if _, err := fmt.Fprintf(w, "%d %d\n", len(model.Vocab), model.VecDim); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4*model.VecDim)
+	for _, voc := range model.Vocab {
+		for i, elem := range voc.Vector.RawVector().Data {
+			binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(elem)))
+		}
+		w.WriteString(voc.Literal + " ")
+		w.Write(buf)
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plausibleHeader reports whether a file of fileSize bytes can hold size vectors of dim values
+// taking at least valueBytes each, so a corrupt header fails before the vectors are allocated
+func plausibleHeader(size int, dim int, valueBytes int64, fileSize int64) bool {
+	if size < 0 || dim <= 0 {
+		return false
+	}
+	return size == 0 || int64(dim) <= fileSize/valueBytes && int64(size) <= fileSize/valueBytes/int64(dim)
+}
+
+// readHeader parses the "size dim" line shared by the text and binary format
+// and checks it against the fileSize
+func readHeader(r *bufio.Reader, valueBytes int64, fileSize int64) (int, int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return 0, 0, &HeaderError{Header: line}
+	}
+
+	size, errSize := strconv.Atoi(fields[0])
+	dim, errDim := strconv.Atoi(fields[1])
+	if errSize != nil || errDim != nil || !plausibleHeader(size, dim, valueBytes, fileSize) {
+		return 0, 0, &HeaderError{Header: line}
+	}
+
+	return size, dim, nil
+}
+
+// readText reads the text format, a value takes at least 2 bytes like "0 "
+func readText(r *bufio.Reader, fileSize int64) ([]string, []float64, int, error) {
+	size, dim, err := readHeader(r, 2, fileSize)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	words := make([]string, 0, size)
+	data := make([]float64, 0, size*dim)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < dim+1 {
+			return nil, nil, 0, &DimensionError{Word: fields[0], Expected: dim, Got: len(fields) - 1}
+		}
+
+		// words may contain spaces, the vector is always the last dim fields. A number among the
+		// extra fields means the vector is longer than dim rather than a word with spaces
+		split := len(fields) - dim
+		for _, field := range fields[1:split] {
+			if _, err := strconv.ParseFloat(field, 64); err == nil {
+				return nil, nil, 0, &DimensionError{Word: fields[0], Expected: dim, Got: len(fields) - 1}
+			}
+		}
+		for _, strFloat := range fields[split:] {
+			n, err := strconv.ParseFloat(strFloat, 64)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			data = append(data, n)
+		}
+		words = append(words, strings.Join(fields[:split], " "))
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	if len(words) != size {
+		return nil, nil, 0, fmt.Errorf("word2vec: header announces %d words, found %d", size, len(words))
+	}
+
+	return words, data, dim, nil
+}
+
+func readBinary(r *bufio.Reader, fileSize int64) ([]string, []float64, int, error) {
+	size, dim, err := readHeader(r, 4, fileSize)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	words := make([]string, 0, size)
+	data := make([]float64, 0, size*dim)
+	if size == 0 {
+		// the header only bounds dim if there are vectors
+		return words, data, dim, nil
+	}
+	buf := make([]byte, 4*dim)
+
+	for i := 0; i < size; i++ {
+		word, err := r.ReadString(' ')
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("word2vec: reading word %d: %v", i, err)
+		}
+		// the C tool terminates every vector with a newline, gensim does not
+		word = strings.TrimLeft(strings.TrimSuffix(word, " "), "\n")
+
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, nil, 0, fmt.Errorf("word2vec: reading vector of %q: %v", word, err)
+		}
+		for j := 0; j < dim; j++ {
+			data = append(data, float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*j:]))))
+		}
+		words = append(words, word)
+	}
+
+	return words, data, dim, nil
+}
+
+// newLoadedModel creates a model from words and their row-major vectors.
+// Counts are unknown, like gensim they are faked from the rank of the word.
+func newLoadedModel(words []string, data []float64, dim int) *Model {
+	model := newModel(50, 5, dim)
+	if len(words) == 0 {
+		return model
+	}
+	model.Syn0 = mat64.NewDense(len(words), dim, data)
+
+	for index, word := range words {
+		phrase := &Phrase{
+			Literal: word,
+			Count:   len(words) - index,
+			Id:      index,
+			Vector:  model.Syn0.RowView(index),
+		}
+		model.Vocab = append(model.Vocab, phrase)
+		model.RawVocab[word] = phrase
+		model.Word2Index[word] = index
+	}
+
+	return InitSims(model)
+}
+
+// newDerivedModel creates a model with the words, counts and tokenizer of model and new vectors
+func newDerivedModel(model *Model, data []float64) *Model {
+	words := make([]string, len(model.Vocab))
+	for idx, phraseObj := range model.Vocab {
+		words[idx] = phraseObj.Literal
+	}
+
+	derived := newLoadedModel(words, data, model.VecDim)
+	for idx, phraseObj := range model.Vocab {
+		derived.Vocab[idx].Count = phraseObj.Count
+	}
+	derived.Tokenizer = model.Tokenizer
+	return derived
+}
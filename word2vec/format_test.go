@@ -0,0 +1,105 @@
+package word2vec
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadFormat(t *testing.T) {
+	fmt.Println("TestSaveLoadFormat")
+	model, err := InitModel(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = BuildVocab(tinySentences, model)
+	model = ScaleVocab(model)
+	model = resetWeights(model)
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, format := range []Format{Text, Binary} {
+		path := filepath.Join(dir, fmt.Sprintf("model-%d", format))
+		if err := SaveFormat(model, path, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadFormat(path, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.VecDim != model.VecDim {
+			t.Errorf("Got dimension %d instead of %d", loaded.VecDim, model.VecDim)
+		}
+		if len(loaded.Vocab) != len(model.Vocab) {
+			t.Fatalf("Got %d instead of %d words", len(loaded.Vocab), len(model.Vocab))
+		}
+
+		for _, phraseObj := range model.Vocab {
+			idx, ok := loaded.Word2Index[phraseObj.Literal]
+			if !ok {
+				t.Errorf("%s missing after loading format %d", phraseObj.Literal, format)
+				continue
+			}
+			for i, v := range phraseObj.Vector.RawVector().Data {
+				if got := loaded.Vocab[idx].Vector.At(i, 0); math.Abs(got-v) > 1e-6 {
+					t.Errorf("Got %f instead of %f for %s in format %d", got, v, phraseObj.Literal, format)
+				}
+			}
+		}
+	}
+}
+
+func TestLoadFormatMalformed(t *testing.T) {
+	fmt.Println("TestLoadFormatMalformed")
+	text := "2 3\nfoo 0.1 0.2 0.3\nbar 0.1 0.2\n"
+	if _, _, _, err := readText(bufio.NewReader(strings.NewReader(text)), int64(len(text))); err == nil {
+		t.Errorf("Expected an error for a short vector")
+	}
+
+	text = "1 3\nfoo 0.1 0.2 0.3 0.4\n"
+	if _, _, _, err := readText(bufio.NewReader(strings.NewReader(text)), int64(len(text))); err == nil {
+		t.Errorf("Expected an error for a long vector")
+	} else if _, ok := err.(*DimensionError); !ok {
+		t.Errorf("Got %T instead of *DimensionError for a long vector", err)
+	}
+
+	text = "1 3\nnew york 0.1 0.2 0.3\n"
+	if words, _, _, err := readText(bufio.NewReader(strings.NewReader(text)), int64(len(text))); err != nil || words[0] != "new york" {
+		t.Errorf("Got %v, %v instead of the word new york", words, err)
+	}
+
+	text = "3\nfoo 0.1 0.2 0.3\n"
+	if _, _, _, err := readText(bufio.NewReader(strings.NewReader(text)), int64(len(text))); err == nil {
+		t.Errorf("Expected an error for a malformed header")
+	}
+
+	// headers announcing more vectors than the file can hold fail before allocating them
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, format := range []Format{Text, Binary} {
+		for _, header := range []string{"99999999999 300", "2 99999999999"} {
+			path := filepath.Join(dir, "model")
+			if err := ioutil.WriteFile(path, []byte(header+"\nfoo 0.1 0.2 0.3\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadFormat(path, format)
+			if _, ok := err.(*HeaderError); !ok {
+				t.Errorf("Got %v instead of a HeaderError for %q in format %d", err, header, format)
+			}
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package word2vec
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Approximate nearest neighbour search with a Hierarchical Navigable Small World graph
+// after Malkov & Yashunin: https://arxiv.org/abs/1603.09320
+// Every word is a node on layer 0 and, with exponentially decaying probability, on the
+// layers above. A query descends greedily from the sparse top layer and runs a best-first
+// search with EfSearch candidates on layer 0. Vectors are stored with unit length, so
+// the cosine similarity is a dot product and the distance is 1 - cosine.
+
+// HNSWConfig trades recall for speed
+type HNSWConfig struct {
+	M              int   // neighbours per node on the upper layers, 2*M on layer 0
+	EfConstruction int   // candidates considered while inserting, higher builds a better graph
+	EfSearch       int   // candidates considered while searching, higher gives better recall
+	ExactBelow     int   // indexes with fewer words are searched exhaustively
+	Seed           int64 // seeds the random layer assignment
+}
+
+// DefaultHNSWConfig returns the parameters recommended in the paper
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       50,
+		ExactBelow:     1000,
+		Seed:           7456393,
+	}
+}
+
+// HNSWIndex answers top-k cosine similarity queries over the vectors of a model.
+// It is read-only after NewHNSWIndex and safe for concurrent searches.
+type HNSWIndex struct {
+	Config     HNSWConfig
+	Words      []string
+	Vectors    [][]float64 // unit length
+	Links      [][][]int   // node -> layer -> neighbours
+	Entry      int
+	MaxLevel   int
+	word2Index map[string]int
+}
+
+type hnswItem struct {
+	id   int
+	dist float64
+}
+
+// nearestHeap pops the closest item first
+type nearestHeap []hnswItem
+
+func (h nearestHeap) Len() int            { return len(h) }
+func (h nearestHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h nearestHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nearestHeap) Push(x interface{}) { *h = append(*h, x.(hnswItem)) }
+func (h *nearestHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// farthestHeap pops the most distant item first
+type farthestHeap []hnswItem
+
+func (h farthestHeap) Len() int            { return len(h) }
+func (h farthestHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h farthestHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *farthestHeap) Push(x interface{}) { *h = append(*h, x.(hnswItem)) }
+func (h *farthestHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// NewHNSWIndex builds the graph over all words of the model
+func NewHNSWIndex(model *Model, config HNSWConfig) *HNSWIndex {
+	if config.M < 2 {
+		config.M = 2
+	}
+	if config.EfConstruction < config.M {
+		config.EfConstruction = config.M
+	}
+
+	index := &HNSWIndex{
+		Config:     config,
+		Words:      make([]string, 0, len(model.Vocab)),
+		Vectors:    make([][]float64, 0, len(model.Vocab)),
+		Links:      make([][][]int, 0, len(model.Vocab)),
+		Entry:      -1,
+		word2Index: make(map[string]int),
+	}
+
+	r := rand.New(rand.NewSource(config.Seed))
+	mL := 1 / math.Log(float64(config.M))
+
+	for _, phraseObj := range model.Vocab {
+		level := int(-math.Log(1-r.Float64()) * mL)
+		index.insert(phraseObj.Literal, unitVector(phraseObj.Vector.RawVector().Data), level)
+	}
+
+	return index
+}
+
+func unitVector(v []float64) []float64 {
+	length := 0.0
+	for _, x := range v {
+		length += x * x
+	}
+	length = math.Sqrt(length)
+
+	u := make([]float64, len(v))
+	for i, x := range v {
+		if length > 0 {
+			u[i] = x / length
+		}
+	}
+	return u
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func (h *HNSWIndex) distance(q []float64, id int) float64 {
+	return 1 - dot(q, h.Vectors[id])
+}
+
+func (h *HNSWIndex) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * h.Config.M
+	}
+	return h.Config.M
+}
+
+func (h *HNSWIndex) insert(word string, vec []float64, level int) {
+	id := len(h.Words)
+	h.Words = append(h.Words, word)
+	h.Vectors = append(h.Vectors, vec)
+	h.Links = append(h.Links, make([][]int, level+1))
+	h.word2Index[word] = id
+
+	if h.Entry < 0 {
+		h.Entry = id
+		h.MaxLevel = level
+		return
+	}
+
+	ep := hnswItem{h.Entry, h.distance(vec, h.Entry)}
+	for lc := h.MaxLevel; lc > level; lc-- {
+		ep = h.greedy(vec, ep, lc)
+	}
+
+	eps := []hnswItem{ep}
+	for lc := minInt(level, h.MaxLevel); lc >= 0; lc-- {
+		candidates := h.searchLayer(vec, eps, h.Config.EfConstruction, lc)
+		neighbours := h.selectNeighbours(candidates, h.Config.M)
+
+		for _, n := range neighbours {
+			h.Links[id][lc] = append(h.Links[id][lc], n.id)
+			h.Links[n.id][lc] = append(h.Links[n.id][lc], id)
+
+			if len(h.Links[n.id][lc]) > h.maxLinks(lc) {
+				h.shrink(n.id, lc)
+			}
+		}
+		eps = candidates
+	}
+
+	if level > h.MaxLevel {
+		h.MaxLevel = level
+		h.Entry = id
+	}
+}
+
+// shrink keeps the best maxLinks neighbours of node on layer lc
+func (h *HNSWIndex) shrink(node int, lc int) {
+	candidates := make([]hnswItem, 0, len(h.Links[node][lc]))
+	for _, n := range h.Links[node][lc] {
+		candidates = append(candidates, hnswItem{n, h.distance(h.Vectors[node], n)})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+
+	kept := h.selectNeighbours(candidates, h.maxLinks(lc))
+	links := make([]int, 0, len(kept))
+	for _, n := range kept {
+		links = append(links, n.id)
+	}
+	h.Links[node][lc] = links
+}
+
+// selectNeighbours is the heuristic of the paper (algorithm 4): a candidate is only
+// linked if it is closer to the new node than to all neighbours selected so far, which
+// keeps links into other clusters. Free slots are filled with the closest pruned ones.
+// candidates must be sorted by distance.
+func (h *HNSWIndex) selectNeighbours(candidates []hnswItem, m int) []hnswItem {
+	if len(candidates) <= m {
+		return candidates
+	}
+
+	selected := make([]hnswItem, 0, m)
+	pruned := make([]hnswItem, 0)
+
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+
+		good := true
+		for _, s := range selected {
+			if h.distance(h.Vectors[c.id], s.id) < c.dist {
+				good = false
+				break
+			}
+		}
+
+		if good {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+
+	for _, c := range pruned {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// greedy walks to the closest node of layer lc
+func (h *HNSWIndex) greedy(q []float64, ep hnswItem, lc int) hnswItem {
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.Links[ep.id][lc] {
+			if d := h.distance(q, n); d < ep.dist {
+				ep = hnswItem{n, d}
+				changed = true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef nodes of layer lc closest to q, sorted by distance
+func (h *HNSWIndex) searchLayer(q []float64, eps []hnswItem, ef int, lc int) []hnswItem {
+	visited := make(map[int]bool, ef*4)
+	candidates := make(nearestHeap, 0, ef)
+	results := make(farthestHeap, 0, ef+1)
+
+	for _, ep := range eps {
+		if visited[ep.id] {
+			continue
+		}
+		visited[ep.id] = true
+		heap.Push(&candidates, ep)
+		heap.Push(&results, ep)
+		if results.Len() > ef {
+			heap.Pop(&results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(&candidates).(hnswItem)
+		if c.dist > results[0].dist && results.Len() >= ef {
+			break
+		}
+
+		for _, n := range h.Links[c.id][lc] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+
+			d := h.distance(q, n)
+			if results.Len() < ef || d < results[0].dist {
+				heap.Push(&candidates, hnswItem{n, d})
+				heap.Push(&results, hnswItem{n, d})
+				if results.Len() > ef {
+					heap.Pop(&results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]hnswItem, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(&results).(hnswItem)
+	}
+	return sorted
+}
+
+// Search returns the top words by cosine similarity to vec.
+// Small indexes (see HNSWConfig.ExactBelow) are searched exhaustively.
+func (h *HNSWIndex) Search(vec *mat64.Vector, top int) ([]SimPair, error) {
+	q, err := h.query(vec)
+	if err != nil {
+		return nil, err
+	}
+	return h.search(q, top, -1), nil
+}
+
+// SearchWord returns the top words most similar to word, without word itself
+func (h *HNSWIndex) SearchWord(word string, top int) ([]SimPair, error) {
+	id, ok := h.word2Index[word]
+	if !ok {
+		return nil, &UnknownWordError{Word: word}
+	}
+	return h.search(h.Vectors[id], top, id), nil
+}
+
+// SearchExact scans all vectors with a bounded heap, the exact fallback of Search
+func (h *HNSWIndex) SearchExact(vec *mat64.Vector, top int) ([]SimPair, error) {
+	q, err := h.query(vec)
+	if err != nil {
+		return nil, err
+	}
+	return h.searchExact(q, top, -1), nil
+}
+
+// query returns vec with unit length, an empty index accepts any dimension
+func (h *HNSWIndex) query(vec *mat64.Vector) ([]float64, error) {
+	data := vec.RawVector().Data
+	if len(h.Vectors) > 0 && len(data) != len(h.Vectors[0]) {
+		return nil, &DimensionError{Word: "query", Expected: len(h.Vectors[0]), Got: len(data)}
+	}
+	return unitVector(data), nil
+}
+
+func (h *HNSWIndex) search(q []float64, top int, exclude int) []SimPair {
+	if h.Entry < 0 || top <= 0 {
+		return []SimPair{}
+	}
+	if len(h.Words) < h.Config.ExactBelow {
+		return h.searchExact(q, top, exclude)
+	}
+
+	want := top
+	if exclude >= 0 {
+		want++
+	}
+
+	ep := hnswItem{h.Entry, h.distance(q, h.Entry)}
+	for lc := h.MaxLevel; lc > 0; lc-- {
+		ep = h.greedy(q, ep, lc)
+	}
+
+	found := h.searchLayer(q, []hnswItem{ep}, maxInt(h.Config.EfSearch, want), 0)
+
+	res := make([]SimPair, 0, top)
+	for _, item := range found {
+		if item.id == exclude {
+			continue
+		}
+		if len(res) == top {
+			break
+		}
+		res = append(res, SimPair{h.Words[item.id], 1 - item.dist})
+	}
+	return res
+}
+
+func (h *HNSWIndex) searchExact(q []float64, top int, exclude int) []SimPair {
+	if top <= 0 {
+		return []SimPair{}
+	}
+
+	results := make(farthestHeap, 0, top+1)
+	for id := range h.Vectors {
+		if id == exclude {
+			continue
+		}
+
+		d := h.distance(q, id)
+		if results.Len() < top || d < results[0].dist {
+			heap.Push(&results, hnswItem{id, d})
+			if results.Len() > top {
+				heap.Pop(&results)
+			}
+		}
+	}
+
+	res := make([]SimPair, results.Len())
+	for i := len(res) - 1; i >= 0; i-- {
+		item := heap.Pop(&results).(hnswItem)
+		res[i] = SimPair{h.Words[item.id], 1 - item.dist}
+	}
+	return res
+}
+
+// Save writes the index with gob to path
+func (h *HNSWIndex) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := gob.NewEncoder(w).Encode(h); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// LoadHNSWIndex reads an index written by Save
+func LoadHNSWIndex(path string) (*HNSWIndex, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	h := &HNSWIndex{}
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(h); err != nil {
+		return nil, err
+	}
+
+	if len(h.Links) != len(h.Words) || len(h.Vectors) != len(h.Words) {
+		return nil, fmt.Errorf("word2vec: corrupt index with %d words, %d vectors and %d nodes", len(h.Words), len(h.Vectors), len(h.Links))
+	}
+	if err := h.checkGraph(); err != nil {
+		return nil, err
+	}
+
+	h.word2Index = make(map[string]int, len(h.Words))
+	for id, word := range h.Words {
+		h.word2Index[word] = id
+	}
+	return h, nil
+}
+
+// checkGraph verifies that a decoded index can be searched without indexing out of range
+func (h *HNSWIndex) checkGraph() error {
+	if len(h.Words) == 0 {
+		if h.Entry != -1 {
+			return fmt.Errorf("word2vec: corrupt index with entry node %d and no words", h.Entry)
+		}
+		return nil
+	}
+	if h.Entry < 0 || h.Entry >= len(h.Words) {
+		return fmt.Errorf("word2vec: corrupt index with entry node %d of %d", h.Entry, len(h.Words))
+	}
+
+	maxLevel := -1
+	for id, layers := range h.Links {
+		if len(h.Vectors[id]) != len(h.Vectors[0]) {
+			return &DimensionError{Word: h.Words[id], Expected: len(h.Vectors[0]), Got: len(h.Vectors[id])}
+		}
+		if len(layers) == 0 {
+			return fmt.Errorf("word2vec: corrupt index, node %d is not on layer 0", id)
+		}
+		maxLevel = maxInt(maxLevel, len(layers)-1)
+
+		for lc, links := range layers {
+			for _, n := range links {
+				if n < 0 || n >= len(h.Links) || len(h.Links[n]) <= lc {
+					return fmt.Errorf("word2vec: corrupt index, node %d links to %d on layer %d", id, n, lc)
+				}
+			}
+		}
+	}
+
+	if h.MaxLevel != maxLevel || len(h.Links[h.Entry])-1 != maxLevel {
+		return fmt.Errorf("word2vec: corrupt index with top layer %d, the nodes reach layer %d", h.MaxLevel, maxLevel)
+	}
+	return nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
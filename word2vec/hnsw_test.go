@@ -0,0 +1,180 @@
+package word2vec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// randomModel returns a model of size words with random vectors
+func randomModel(size int, dim int, seed int64) *Model {
+	r := rand.New(rand.NewSource(seed))
+	words := make([]string, size)
+	data := make([]float64, size*dim)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	for i := range data {
+		data[i] = r.NormFloat64()
+	}
+	return newLoadedModel(words, data, dim)
+}
+
+func recall(expected []SimPair, got []SimPair) float64 {
+	hits := 0
+	for _, e := range expected {
+		for _, g := range got {
+			if e.Key == g.Key {
+				hits++
+				break
+			}
+		}
+	}
+	return float64(hits) / float64(len(expected))
+}
+
+func TestHNSWIndex(t *testing.T) {
+	fmt.Println("TestHNSWIndex")
+	model := randomModel(1000, 16, 1)
+
+	config := DefaultHNSWConfig()
+	config.ExactBelow = 0
+	index := NewHNSWIndex(model, config)
+
+	total := 0.0
+	for _, phraseObj := range model.Vocab[:50] {
+		expected, err := index.SearchExact(phraseObj.Vector, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := index.Search(phraseObj.Vector, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 10 {
+			t.Fatalf("Got %d instead of 10 results", len(got))
+		}
+		total += recall(expected, got)
+	}
+
+	if r := total / 50; r < 0.9 {
+		t.Errorf("Got recall@10 of %f, expected at least 0.9", r)
+	}
+
+	// the exact search must agree with MostSimilarByVector
+	vec := model.Vocab[0].Vector
+	exact, err := index.SearchExact(vec, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brute := MostSimilarByVector(vec, 5, model)
+	for i := range exact {
+		if exact[i].Key != brute[i].Key {
+			t.Errorf("Got %s instead of %s at rank %d", exact[i].Key, brute[i].Key, i)
+		}
+	}
+
+	ans, err := index.SearchWord("w1", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range ans {
+		if pair.Key == "w1" {
+			t.Errorf("SearchWord returned the query word")
+		}
+	}
+
+	if _, err := index.SearchWord("unicorn", 5); err == nil {
+		t.Errorf("Expected an error for an unknown word")
+	}
+
+	for _, top := range []int{0, -1} {
+		if got, err := index.SearchExact(vec, top); err != nil || len(got) != 0 {
+			t.Errorf("Got %d results and error %v for top %d", len(got), err, top)
+		}
+	}
+
+	short := mat64.NewVector(3, nil)
+	if _, err := index.Search(short, 5); err == nil {
+		t.Errorf("Expected an error for a query of dimension 3")
+	} else if _, ok := err.(*DimensionError); !ok {
+		t.Errorf("Got %T instead of *DimensionError", err)
+	}
+	if _, err := index.SearchExact(short, 5); err == nil {
+		t.Errorf("Expected an error for an exact query of dimension 3")
+	}
+}
+
+func TestHNSWIndexSaveLoad(t *testing.T) {
+	fmt.Println("TestHNSWIndexSaveLoad")
+	model := randomModel(200, 8, 2)
+
+	config := DefaultHNSWConfig()
+	config.ExactBelow = 0
+	index := NewHNSWIndex(model, config)
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "index.gob")
+	if err := index.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHNSWIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, word := range []string{"w0", "w42", "w199"} {
+		expected, _ := index.SearchWord(word, 5)
+		got, err := loaded.SearchWord(word, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			if expected[i] != got[i] {
+				t.Errorf("Got %v instead of %v for %s after loading", got[i], expected[i], word)
+			}
+		}
+	}
+}
+
+func TestLoadHNSWIndexCorrupt(t *testing.T) {
+	fmt.Println("TestLoadHNSWIndexCorrupt")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	corruptions := map[string]func(h *HNSWIndex){
+		"entry out of range":  func(h *HNSWIndex) { h.Entry = len(h.Words) },
+		"wrong top layer":     func(h *HNSWIndex) { h.MaxLevel++ },
+		"link out of range":   func(h *HNSWIndex) { h.Links[0][0][0] = len(h.Words) },
+		"negative link":       func(h *HNSWIndex) { h.Links[1][0][0] = -1 },
+		"node without layers": func(h *HNSWIndex) { h.Links[2] = nil },
+	}
+	for name, corrupt := range corruptions {
+		config := DefaultHNSWConfig()
+		config.ExactBelow = 0
+		index := NewHNSWIndex(randomModel(50, 4, 3), config)
+		corrupt(index)
+
+		path := filepath.Join(dir, "index.gob")
+		if err := index.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadHNSWIndex(path); err == nil {
+			t.Errorf("Expected an error for an index with %s", name)
+		}
+	}
+}
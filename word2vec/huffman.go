@@ -0,0 +1,124 @@
+package word2vec
+
+import (
+	"container/heap"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Hierarchical softmax after Morin & Bengio as used in the C tool:
+// every word is a leaf of a Huffman tree built over the word counts, the inner nodes
+// own a row in Syn1 and a word is predicted by walking from the root to its leaf.
+
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/word2vec.py (create_binary_tree)
+
+type huffmanNode struct {
+	count int
+	index int
+	left  *huffmanNode
+	right *huffmanNode
+}
+
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].count == h[j].count {
+		return h[i].index < h[j].index
+	}
+	return h[i].count < h[j].count
+}
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// createBinaryTree builds the Huffman tree over model.Vocab and stores the
+// binary code and the inner node indices (points) of the path in every Phrase
+func createBinaryTree(model *Model) *Model {
+	vocabSize := len(model.Vocab)
+	if vocabSize == 0 {
+		return model
+	}
+
+	h := make(huffmanHeap, 0, vocabSize)
+	for index, phraseObj := range model.Vocab {
+		h = append(h, &huffmanNode{count: phraseObj.Count, index: index})
+	}
+	heap.Init(&h)
+
+	// inner nodes get the indices vocabSize, vocabSize+1, ...
+	for i := 0; h.Len() > 1; i++ {
+		min1 := heap.Pop(&h).(*huffmanNode)
+		min2 := heap.Pop(&h).(*huffmanNode)
+		heap.Push(&h, &huffmanNode{
+			count: min1.count + min2.count,
+			index: vocabSize + i,
+			left:  min1,
+			right: min2,
+		})
+	}
+
+	type stackItem struct {
+		node   *huffmanNode
+		code   []uint8
+		points []int
+	}
+
+	stack := []stackItem{{node: h[0], code: []uint8{}, points: []int{}}}
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if item.node.index < vocabSize {
+			model.Vocab[item.node.index].Code = item.code
+			model.Vocab[item.node.index].Point = item.points
+			continue
+		}
+
+		points := make([]int, len(item.points), len(item.points)+1)
+		copy(points, item.points)
+		points = append(points, item.node.index-vocabSize)
+
+		stack = append(stack, stackItem{item.node.left, appendCode(item.code, 0), points})
+		stack = append(stack, stackItem{item.node.right, appendCode(item.code, 1), points})
+	}
+
+	return model
+}
+
+func appendCode(code []uint8, bit uint8) []uint8 {
+	c := make([]uint8, len(code), len(code)+1)
+	copy(c, code)
+	return append(c, bit)
+}
+
+// trainHS predicts word from the hidden layer l1 with hierarchical softmax.
+// Syn1 is updated in place, the error for l1 is accumulated into neu1e and the loss is returned.
+func trainHS(model *Model, word *Phrase, l1 *mat64.Vector, neu1e *mat64.Vector, alpha float64, learnHidden bool) float64 {
+	loss := 0.0
+
+	for d, point := range word.Point {
+		l2 := model.Syn1.RowView(point)
+		f := mat64.Dot(l1, l2)
+		g := (1.0 - float64(word.Code[d]) - sigmoid(f)) * alpha
+
+		// gensim: the label of the inner node is 1 - code
+		if word.Code[d] == 0 {
+			loss -= logSigmoid(f)
+		} else {
+			loss -= logSigmoid(-f)
+		}
+
+		neu1e.AddScaledVec(neu1e, g, l2)
+		if learnHidden {
+			l2.AddScaledVec(l2, g, l1)
+		}
+	}
+
+	return loss
+}
@@ -0,0 +1,69 @@
+package word2vec
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestCreateBinaryTree(t *testing.T) {
+	fmt.Println("TestCreateBinaryTree")
+	model, err := InitModel(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = BuildVocab(tinySentences, model)
+	model = ScaleVocab(model)
+	model = createBinaryTree(model)
+
+	codes := make([]string, 0)
+	for _, phraseObj := range model.Vocab {
+		if len(phraseObj.Code) != len(phraseObj.Point) {
+			t.Errorf("%s has %d code bits but %d points", phraseObj.Literal, len(phraseObj.Code), len(phraseObj.Point))
+		}
+		for _, point := range phraseObj.Point {
+			if point >= len(model.Vocab)-1 {
+				t.Errorf("%s points to inner node %d", phraseObj.Literal, point)
+			}
+		}
+		codes = append(codes, fmt.Sprint(phraseObj.Code))
+	}
+
+	// no code may be the prefix of another one
+	for i, a := range codes {
+		for j, b := range codes {
+			if i != j && strings.HasPrefix(b, strings.TrimSuffix(a, "]")) {
+				t.Errorf("code %s is a prefix of %s", a, b)
+			}
+		}
+	}
+
+	// "want" and "a" occur twice and must not get longer codes than the other words
+	want := model.Vocab[model.Word2Index["want"]]
+	dog := model.Vocab[model.Word2Index["dog"]]
+	if len(want.Code) > len(dog.Code) {
+		t.Errorf("Got a longer code for want (%d) than for dog (%d)", len(want.Code), len(dog.Code))
+	}
+}
+
+func TestTrainHS(t *testing.T) {
+	fmt.Println("TestTrainHS")
+	for _, arch := range []Architecture{SkipGram, CBOW} {
+		config := testConfig(2)
+		config.Architecture = arch
+		model := testModel(t, config, tinySentences)
+
+		if model.Syn1Neg != nil || len(model.CumTable) != 0 {
+			t.Errorf("Negative sampling structures allocated with Negative = 0")
+		}
+
+		model = Train(tinySentences, model)
+
+		r, c := model.Syn1.Dims()
+		if mat64.Equal(model.Syn1, mat64.NewDense(r, c, nil)) {
+			t.Errorf("Hierarchical softmax training did not update Syn1 (architecture %d)", arch)
+		}
+	}
+}
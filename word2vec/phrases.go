@@ -0,0 +1,168 @@
+package word2vec
+
+import (
+	"math"
+	"strings"
+)
+
+// Collocation detection after Mikolov et al. section 4: https://arxiv.org/abs/1310.4546
+// Adjacent tokens that occur together often enough are joined ("new york" -> "new_york"),
+// running a second detector over the joined corpus yields trigrams ("new_york_times").
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/phrases.py
+
+type PhraseScoring int
+
+const (
+	MikolovScoring PhraseScoring = iota // (count(a b) - min_count) / count(a) / count(b) * vocab_size
+	NPMIScoring                         // normalized pointwise mutual information in [-1, 1]
+)
+
+// PhraseDetector counts unigrams and bigrams of a corpus and scores bigrams.
+// Sentence elements are split by Tokenizer like in BuildVocab, an element without
+// tokens (e.g. punctuation) separates bigrams.
+type PhraseDetector struct {
+	MinCount        int     // bigrams seen less often are never joined
+	Threshold       float64 // bigrams scoring above it are joined, gensim uses 10 for Mikolov and 0.5 for NPMI
+	Scoring         PhraseScoring
+	Delimiter       string         // joins the tokens of a detected bigram, must be kept by Tokenizer
+	Tokenizer       Tokenizer      // nil uses DefaultTokenizer
+	WordCounts      map[string]int // unigram counts
+	BigramCounts    map[string]int // bigram counts, keyed by "a b" (vocabulary forms never contain spaces)
+	CorpusWordCount int
+}
+
+// NewPhraseDetector creates a detector with Mikolov scoring and "_" as delimiter
+func NewPhraseDetector(minCount int, threshold float64) *PhraseDetector {
+	return &PhraseDetector{
+		MinCount:     minCount,
+		Threshold:    threshold,
+		Scoring:      MikolovScoring,
+		Delimiter:    "_",
+		WordCounts:   make(map[string]int),
+		BigramCounts: make(map[string]int),
+	}
+}
+
+// Learn adds the unigram and bigram counts of corpus, it can be called for several corpora
+func (d *PhraseDetector) Learn(corpus Corpus) error {
+	return corpus.Iterate(func(sentence []string) error {
+		words, separated := d.tokens(sentence)
+		for i, word := range words {
+			d.WordCounts[word]++
+			d.CorpusWordCount++
+
+			if i > 0 && !separated[i] {
+				d.BigramCounts[words[i-1]+" "+word]++
+			}
+		}
+		return nil
+	})
+}
+
+// Score returns the score of the bigram "a b", math.Inf(-1) if it cannot be a phrase
+func (d *PhraseDetector) Score(a string, b string) float64 {
+	tokensA, tokensB := d.tokenizer().Tokenize(a), d.tokenizer().Tokenize(b)
+	if len(tokensA) != 1 || len(tokensB) != 1 {
+		return math.Inf(-1)
+	}
+	return d.scoreTokens(tokensA[0], tokensB[0])
+}
+
+// scoreTokens is Score for words that are already tokenized, tokenizing again is not idempotent
+// (e.g. NumberToken "<num>" becomes "num")
+func (d *PhraseDetector) scoreTokens(a string, b string) float64 {
+	countA, countB := d.WordCounts[a], d.WordCounts[b]
+	countAB := d.BigramCounts[a+" "+b]
+	if countAB == 0 || countAB < d.MinCount {
+		return math.Inf(-1)
+	}
+
+	if d.Scoring == NPMIScoring {
+		total := float64(d.CorpusWordCount)
+		pa, pb, pab := float64(countA)/total, float64(countB)/total, float64(countAB)/total
+		if pab >= 1 {
+			return 1
+		}
+		return math.Log(pab/(pa*pb)) / -math.Log(pab)
+	}
+
+	return float64(countAB-d.MinCount) / float64(countA) / float64(countB) * float64(len(d.WordCounts)+len(d.BigramCounts))
+}
+
+// Transform tokenizes sentence and joins the bigrams scoring above Threshold, left to right,
+// a token is part of at most one bigram per pass
+func (d *PhraseDetector) Transform(sentence []string) []string {
+	words, separated := d.tokens(sentence)
+	result := make([]string, 0, len(words))
+
+	for i := 0; i < len(words); i++ {
+		if i+1 < len(words) && !separated[i+1] && d.scoreTokens(words[i], words[i+1]) > d.Threshold {
+			result = append(result, words[i]+d.Delimiter+words[i+1])
+			i++
+			continue
+		}
+		result = append(result, words[i])
+	}
+
+	return result
+}
+
+func (d *PhraseDetector) tokenizer() Tokenizer {
+	if d.Tokenizer == nil {
+		return DefaultTokenizer()
+	}
+	return d.Tokenizer
+}
+
+// tokens tokenizes the elements of sentence, separated[i] is true
+// if an element without tokens comes before words[i]
+func (d *PhraseDetector) tokens(sentence []string) ([]string, []bool) {
+	tokenizer := d.tokenizer()
+	words := make([]string, 0, len(sentence))
+	separated := make([]bool, 0, len(sentence))
+
+	gap := false
+	for _, element := range sentence {
+		tokens := tokenizer.Tokenize(element)
+		if len(tokens) == 0 {
+			gap = true
+			continue
+		}
+
+		for _, token := range tokens {
+			words = append(words, token)
+			separated = append(separated, gap)
+			gap = false
+		}
+	}
+
+	return words, separated
+}
+
+// Phrasegrams returns every bigram scoring above Threshold, joined with Delimiter, with its score
+func (d *PhraseDetector) Phrasegrams() map[string]float64 {
+	phrasegrams := make(map[string]float64)
+
+	for bigram := range d.BigramCounts {
+		words := strings.SplitN(bigram, " ", 2)
+		if score := d.scoreTokens(words[0], words[1]); score > d.Threshold {
+			phrasegrams[words[0]+d.Delimiter+words[1]] = score
+		}
+	}
+
+	return phrasegrams
+}
+
+// PhraseCorpus rewrites the sentences of Corpus with Detector.Transform,
+// pass it to BuildVocabCorpus and TrainCorpus or to another detector for trigrams
+type PhraseCorpus struct {
+	Corpus   Corpus
+	Detector *PhraseDetector
+}
+
+// Iterate calls fn for every transformed sentence
+func (c PhraseCorpus) Iterate(fn func(sentence []string) error) error {
+	return c.Corpus.Iterate(func(sentence []string) error {
+		return fn(c.Detector.Transform(sentence))
+	})
+}
@@ -0,0 +1,117 @@
+package word2vec
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+var phraseSentences = SliceCorpus{
+	strings.Fields("I read the New York Times today"),
+	strings.Fields("the New York Times is a paper"),
+	strings.Fields("New York is big , York is old"),
+	strings.Fields("I read a new book"),
+	strings.Fields("the times were good"),
+	strings.Fields("the paper is the best in the city"),
+}
+
+func TestPhraseScore(t *testing.T) {
+	fmt.Println("TestPhraseScore")
+	d := NewPhraseDetector(2, 1)
+	if err := d.Learn(phraseSentences); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.WordCounts["york"] != 4 || d.BigramCounts["new york"] != 3 {
+		t.Errorf("Got york %d and new york %d instead of 4 and 3", d.WordCounts["york"], d.BigramCounts["new york"])
+	}
+	// "big" and "york" are separated by a comma
+	if d.BigramCounts["big york"] != 0 {
+		t.Errorf("Punctuation did not separate bigrams")
+	}
+
+	// (3 - 2) / 4 / 4 * vocab size
+	expected := 1.0 / 16.0 * float64(len(d.WordCounts)+len(d.BigramCounts))
+	if score := d.Score("New", "York"); math.Abs(score-expected) > 1e-12 {
+		t.Errorf("Got score %f instead of %f", score, expected)
+	}
+	if score := d.Score("read", "a"); !math.IsInf(score, -1) {
+		t.Errorf("Bigram below min count scored %f", score)
+	}
+
+	d.Scoring = NPMIScoring
+	if score := d.Score("new", "york"); score <= 0 || score > 1 {
+		t.Errorf("Got NPMI %f outside (0, 1]", score)
+	}
+}
+
+func TestPhraseTransform(t *testing.T) {
+	fmt.Println("TestPhraseTransform")
+	bigrams := NewPhraseDetector(2, 1)
+	if err := bigrams.Learn(phraseSentences); err != nil {
+		t.Fatal(err)
+	}
+
+	// the output is tokenized like BuildVocab does
+	got := bigrams.Transform(strings.Fields("the New York Times"))
+	if strings.Join(got, " ") != "the new_york times" {
+		t.Errorf("Got %v instead of [the new_york times]", got)
+	}
+
+	if _, ok := bigrams.Phrasegrams()["new_york"]; !ok {
+		t.Errorf("new_york missing from %v", bigrams.Phrasegrams())
+	}
+
+	// a second pass over the rewritten corpus finds the trigram
+	trigrams := NewPhraseDetector(1, 3)
+	if err := trigrams.Learn(PhraseCorpus{phraseSentences, bigrams}); err != nil {
+		t.Fatal(err)
+	}
+
+	model := newModel(1, 1, 10)
+	model, err := BuildVocabCorpus(PhraseCorpus{PhraseCorpus{phraseSentences, bigrams}, trigrams}, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phraseObj, ok := model.RawVocab["new_york_times"]; !ok || phraseObj.Count != 2 {
+		t.Errorf("new_york_times missing from the vocabulary")
+	}
+	if phraseObj, ok := model.RawVocab["new_york"]; !ok || phraseObj.Count != 1 {
+		t.Errorf("new_york missing from the vocabulary")
+	}
+}
+
+func TestPhraseTransformNumberToken(t *testing.T) {
+	fmt.Println("TestPhraseTransformNumberToken")
+	d := NewPhraseDetector(1, 0.1)
+	d.Tokenizer = &UnicodeTokenizer{Lowercase: true, NumberToken: "<num>"}
+	corpus := SliceCorpus{
+		strings.Fields("take route 66 west"),
+		strings.Fields("route 66 is old"),
+		strings.Fields("the old road"),
+	}
+	if err := d.Learn(corpus); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tokens of Transform are scored as they are, "<num>" is not tokenized again to "num"
+	got := d.Transform(strings.Fields("Route 99 today"))
+	if strings.Join(got, " ") != "route_<num> today" {
+		t.Errorf("Got %v instead of [route_<num> today]", got)
+	}
+	if _, ok := d.Phrasegrams()["route_<num>"]; !ok {
+		t.Errorf("Got phrasegrams %v without route_<num>", d.Phrasegrams())
+	}
+
+	// the vocabulary of the transformed corpus keeps the tokens of the detector
+	model := newModel(1, 1, 10)
+	model.Tokenizer = d.Tokenizer
+	model, err := BuildVocabCorpus(PhraseCorpus{Corpus: corpus, Detector: d}, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := model.RawVocab["route_<num>"]; !ok {
+		t.Errorf("Got vocabulary %v without route_<num>", model.RawVocab)
+	}
+}
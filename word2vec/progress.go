@@ -0,0 +1,103 @@
+package word2vec
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Progress is passed to the Observer of a model while it trains
+type Progress struct {
+	Epoch       int     // current epoch, starting at 1
+	Epochs      int     // epochs of the training run
+	EpochEnd    bool    // true for the report after the last sentence of Epoch
+	WordCount   int64   // words processed since the start of the first epoch
+	WordsPerSec float64 // words processed per second in this training call
+	Alpha       float64 // current learning rate
+	Loss        float64 // accumulated loss of the output layers in this training call
+}
+
+// Observer receives training progress. Reports are never sent concurrently.
+type Observer interface {
+	OnProgress(p Progress)
+}
+
+// ObserverFunc adapts a function to the Observer interface
+type ObserverFunc func(p Progress)
+
+// OnProgress calls f(p)
+func (f ObserverFunc) OnProgress(p Progress) {
+	f(p)
+}
+
+// progressReporter collects the loss of all workers and reports to model.Observer
+// every model.ReportInterval words and at the end of every epoch
+type progressReporter struct {
+	mu         sync.Mutex
+	model      *Model
+	start      time.Time
+	startCount int64
+	next       int64
+	loss       float64
+}
+
+func newProgressReporter(model *Model) *progressReporter {
+	return &progressReporter{
+		model:      model,
+		start:      time.Now(),
+		startCount: model.WordCount,
+		next:       model.WordCount + model.ReportInterval,
+	}
+}
+
+// add is called by the workers after every sentence with its loss and the shared word count
+func (p *progressReporter) add(epoch int, loss float64, wordCount int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.loss += loss
+	if p.model.Observer == nil || p.model.ReportInterval <= 0 || wordCount < p.next {
+		return
+	}
+
+	for p.next <= wordCount {
+		p.next += p.model.ReportInterval
+	}
+	p.report(epoch, false, wordCount)
+}
+
+// epochEnd reports the end of epoch and stores the loss in model.TrainingLoss
+func (p *progressReporter) epochEnd(epoch int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.model.TrainingLoss = p.loss
+	if p.model.Observer != nil {
+		p.report(epoch, true, p.model.WordCount)
+	}
+}
+
+func (p *progressReporter) report(epoch int, epochEnd bool, wordCount int64) {
+	wordsPerSec := 0.0
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		wordsPerSec = float64(wordCount-p.startCount) / elapsed
+	}
+
+	p.model.Observer.OnProgress(Progress{
+		Epoch:       epoch,
+		Epochs:      p.model.Epochs,
+		EpochEnd:    epochEnd,
+		WordCount:   wordCount,
+		WordsPerSec: wordsPerSec,
+		Alpha:       currentAlpha(p.model, wordCount),
+		Loss:        p.loss,
+	})
+}
+
+// logSigmoid is log(sigmoid(x)) without overflow for large |x|
+func logSigmoid(x float64) float64 {
+	if x >= 0 {
+		return -math.Log1p(math.Exp(-x))
+	}
+	return x - math.Log1p(math.Exp(x))
+}
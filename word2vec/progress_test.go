@@ -0,0 +1,71 @@
+package word2vec
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestTrainProgress(t *testing.T) {
+	fmt.Println("TestTrainProgress")
+	model, err := InitModel(3, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.Workers = 2
+	model.Sample = 1.0
+	model.ReportInterval = 50
+
+	reports := make([]Progress, 0)
+	model.Observer = ObserverFunc(func(p Progress) {
+		reports = append(reports, p)
+	})
+
+	sens := make([][]string, 0)
+	for i := 0; i < 20; i++ {
+		sens = append(sens, tinySentences...)
+	}
+
+	model = BuildVocab(sens, model)
+	model = ScaleVocab(model)
+	model = FinalizeVocab(model)
+	model = Train(sens, model)
+
+	epochEnds := 0
+	for i, p := range reports {
+		if p.EpochEnd {
+			epochEnds++
+			if p.Epoch != epochEnds || p.WordCount != int64(epochEnds*model.TotalCorpusCount) {
+				t.Errorf("Epoch end %d reported epoch %d after %d words", epochEnds, p.Epoch, p.WordCount)
+			}
+		}
+		if i > 0 && (p.WordCount < reports[i-1].WordCount || p.Alpha > reports[i-1].Alpha || p.Loss < reports[i-1].Loss) {
+			t.Errorf("Report %d went backwards: %+v after %+v", i, p, reports[i-1])
+		}
+	}
+
+	if epochEnds != 3 || len(reports) <= 3 {
+		t.Fatalf("Got %d reports with %d epoch ends instead of periodic reports and 3 epoch ends", len(reports), epochEnds)
+	}
+
+	last := reports[len(reports)-1]
+	if math.Abs(last.Alpha-model.MinAlpha) > 1e-4 {
+		t.Errorf("Got final alpha %f instead of about %f", last.Alpha, model.MinAlpha)
+	}
+	if last.Loss <= 0 || last.Loss != model.TrainingLoss {
+		t.Errorf("Got final loss %f and training loss %f", last.Loss, model.TrainingLoss)
+	}
+}
+
+func TestLogSigmoid(t *testing.T) {
+	fmt.Println("TestLogSigmoid")
+	for _, x := range []float64{-5, -0.5, 0, 0.5, 5} {
+		if got, expected := logSigmoid(x), math.Log(sigmoid(x)); math.Abs(got-expected) > 1e-12 {
+			t.Errorf("logSigmoid(%v) = %v instead of %v", x, got, expected)
+		}
+	}
+
+	if got := logSigmoid(-1000); math.IsInf(got, 0) || math.Abs(got+1000) > 1e-9 {
+		t.Errorf("logSigmoid(-1000) = %v instead of -1000", got)
+	}
+}
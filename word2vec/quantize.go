@@ -0,0 +1,601 @@
+package word2vec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+)
+
+// Quantization is the compression of a QuantizedModel
+type Quantization int
+
+const (
+	// ScalarQuantization stores every component as an int8 scaled per dimension, 4x smaller than float32
+	ScalarQuantization Quantization = iota
+	// ProductQuantization splits the vectors into subspaces and stores the index of the nearest of
+	// up to 256 centroids per subspace, one byte per subspace.
+	// after Jégou et al.: https://hal.inria.fr/inria-00514462
+	ProductQuantization
+)
+
+// pqCentroids is the number of centroids per subspace, the most one byte can address
+const pqCentroids = 256
+
+// QuantizedModel holds compressed word vectors. Queries are not quantized, similarities are
+// computed between the float query and the reconstructed vectors (asymmetric distance computation).
+// It is read-only and safe for concurrent queries.
+type QuantizedModel struct {
+	Quantization Quantization
+	Dim          int
+	Words        []string
+	Codes        []byte // CodeSize bytes per word
+	CodeSize     int
+	Scale        []float64   // scalar: a component is int8(code) * Scale[dim]
+	Codebooks    [][]float64 // product: centroids of each subspace, row-major
+	norms        []float64   // of the reconstructed vectors
+	index        map[string]int
+	tokenizer    Tokenizer // of the model, queries are looked up in the form it produces
+}
+
+// QuantizeScalar compresses the vectors of the model to int8 with one scale per dimension
+func QuantizeScalar(model *Model) *QuantizedModel {
+	q := newQuantizedModel(ScalarQuantization, model.VecDim, model.VecDim, model)
+
+	q.Scale = make([]float64, q.Dim)
+	for _, phraseObj := range model.Vocab {
+		for i, v := range phraseObj.Vector.RawVector().Data {
+			q.Scale[i] = math.Max(q.Scale[i], math.Abs(v)/127)
+		}
+	}
+
+	for idx, phraseObj := range model.Vocab {
+		code := q.Codes[idx*q.CodeSize : (idx+1)*q.CodeSize]
+		for i, v := range phraseObj.Vector.RawVector().Data {
+			if q.Scale[i] > 0 {
+				code[i] = byte(int8(math.Max(-127, math.Min(127, math.Round(v/q.Scale[i])))))
+			}
+		}
+	}
+
+	q.initNorms()
+	return q
+}
+
+// QuantizeProduct compresses the vectors of the model to one byte per subspace. The dimension must
+// be divisible by subspaces, the codebooks are learned by k-means with the given iterations
+// starting from vectors drawn with model.Seed.
+func QuantizeProduct(subspaces int, iterations int, model *Model) (*QuantizedModel, error) {
+	if subspaces < 1 || model.VecDim%subspaces != 0 {
+		return nil, fmt.Errorf("word2vec: dimension %d can not be split into %d subspaces", model.VecDim, subspaces)
+	}
+	if len(model.Vocab) == 0 {
+		return nil, fmt.Errorf("word2vec: can not quantize an empty vocabulary")
+	}
+
+	q := newQuantizedModel(ProductQuantization, model.VecDim, subspaces, model)
+	subDim := q.Dim / subspaces
+	r := rand.New(rand.NewSource(model.Seed))
+
+	vectors := make([][]float64, len(model.Vocab))
+	for idx, phraseObj := range model.Vocab {
+		vectors[idx] = phraseObj.Vector.RawVector().Data
+	}
+
+	q.Codebooks = make([][]float64, subspaces)
+	for m := range q.Codebooks {
+		codebook, assignment := kMeans(vectors, m*subDim, (m+1)*subDim, minInt(pqCentroids, len(vectors)), iterations, r)
+		q.Codebooks[m] = codebook
+		for idx, c := range assignment {
+			q.Codes[idx*q.CodeSize+m] = byte(c)
+		}
+	}
+
+	q.initNorms()
+	return q, nil
+}
+
+// kMeans clusters the components [lo, hi) of vectors into k centroids and returns them with the
+// centroid of every vector. An empty cluster keeps its previous centroid.
+func kMeans(vectors [][]float64, lo int, hi int, k int, iterations int, r *rand.Rand) ([]float64, []int) {
+	subDim := hi - lo
+	centroids := make([]float64, k*subDim)
+	for c, idx := range r.Perm(len(vectors))[:k] {
+		copy(centroids[c*subDim:], vectors[idx][lo:hi])
+	}
+
+	assignment := make([]int, len(vectors))
+	sums := make([]float64, k*subDim)
+	counts := make([]int, k)
+
+	for iter := 0; ; iter++ {
+		for idx, vec := range vectors {
+			assignment[idx] = nearestCentroid(vec[lo:hi], centroids, k)
+		}
+		if iter == iterations {
+			return centroids, assignment
+		}
+
+		for i := range sums {
+			sums[i] = 0
+		}
+		for c := range counts {
+			counts[c] = 0
+		}
+		for idx, c := range assignment {
+			counts[c]++
+			for i, v := range vectors[idx][lo:hi] {
+				sums[c*subDim+i] += v
+			}
+		}
+		for c, count := range counts {
+			if count == 0 {
+				continue
+			}
+			for i := 0; i < subDim; i++ {
+				centroids[c*subDim+i] = sums[c*subDim+i] / float64(count)
+			}
+		}
+	}
+}
+
+// nearestCentroid returns the centroid with the smallest euclidean distance to vec
+func nearestCentroid(vec []float64, centroids []float64, k int) int {
+	subDim := len(vec)
+	best, bestDist := 0, math.Inf(1)
+	for c := 0; c < k; c++ {
+		dist := 0.0
+		for i, v := range vec {
+			d := v - centroids[c*subDim+i]
+			dist += d * d
+		}
+		if dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+	return best
+}
+
+func newQuantizedModel(quantization Quantization, dim int, codeSize int, model *Model) *QuantizedModel {
+	q := &QuantizedModel{
+		Quantization: quantization,
+		Dim:          dim,
+		Words:        make([]string, len(model.Vocab)),
+		Codes:        make([]byte, len(model.Vocab)*codeSize),
+		CodeSize:     codeSize,
+		index:        make(map[string]int, len(model.Vocab)),
+		tokenizer:    model.Tokenizer,
+	}
+	for idx, phraseObj := range model.Vocab {
+		q.Words[idx] = phraseObj.Literal
+		q.index[phraseObj.Literal] = idx
+	}
+	return q
+}
+
+// initNorms computes the lengths of the reconstructed vectors for the cosine similarity
+func (q *QuantizedModel) initNorms() {
+	q.norms = make([]float64, len(q.Words))
+	vec := make([]float64, q.Dim)
+	for idx := range q.Words {
+		q.decode(idx, vec)
+		q.norms[idx] = math.Sqrt(dot64(vec, vec))
+	}
+}
+
+// decode writes the reconstructed vector of the word at idx to vec
+func (q *QuantizedModel) decode(idx int, vec []float64) {
+	code := q.Codes[idx*q.CodeSize : (idx+1)*q.CodeSize]
+	switch q.Quantization {
+	case ScalarQuantization:
+		for i, c := range code {
+			vec[i] = float64(int8(c)) * q.Scale[i]
+		}
+	case ProductQuantization:
+		subDim := q.Dim / q.CodeSize
+		for m, c := range code {
+			copy(vec[m*subDim:(m+1)*subDim], q.Codebooks[m][int(c)*subDim:])
+		}
+	}
+}
+
+// Len returns the number of words
+func (q *QuantizedModel) Len() int {
+	return len(q.Words)
+}
+
+// Vector returns the reconstructed vector of word
+func (q *QuantizedModel) Vector(word string) ([]float64, error) {
+	idx, ok := lookupIndex(q.index, q.tokenizer, word)
+	if !ok {
+		return nil, &UnknownWordError{Word: word}
+	}
+
+	vec := make([]float64, q.Dim)
+	q.decode(idx, vec)
+	return vec, nil
+}
+
+// MostSimilar returns the top words by cosine similarity to the reconstructed vector of word,
+// without word itself
+func (q *QuantizedModel) MostSimilar(word string, top int) ([]SimPair, error) {
+	idx, ok := lookupIndex(q.index, q.tokenizer, word)
+	if !ok {
+		return nil, &UnknownWordError{Word: word}
+	}
+
+	vec := make([]float64, q.Dim)
+	q.decode(idx, vec)
+	return q.mostSimilar(vec, top, map[int]bool{idx: true}), nil
+}
+
+// MostSimilarByVector returns the top words by asymmetric cosine similarity to vec
+func (q *QuantizedModel) MostSimilarByVector(vec []float64, top int) ([]SimPair, error) {
+	if len(vec) != q.Dim {
+		return nil, &DimensionError{Word: "query", Expected: q.Dim, Got: len(vec)}
+	}
+	return q.mostSimilar(vec, top, nil), nil
+}
+
+func (q *QuantizedModel) mostSimilar(vec []float64, top int, exclude map[int]bool) []SimPair {
+	sims := q.similarities(vec)
+	return topSimilarLabels(sims, top, exclude, func(idx int) string { return q.Words[idx] })
+}
+
+// similarities returns the cosine similarity of vec to every word without decoding the vectors:
+// scalar codes are multiplied with the query scaled per dimension, product codes look up the
+// dot products of the query with every centroid of their subspace
+func (q *QuantizedModel) similarities(vec []float64) []float64 {
+	dots := make([]float64, len(q.Words))
+
+	switch q.Quantization {
+	case ScalarQuantization:
+		weights := make([]float64, q.Dim)
+		for i, v := range vec {
+			weights[i] = v * q.Scale[i]
+		}
+		for idx := range dots {
+			sum := 0.0
+			for i, c := range q.Codes[idx*q.CodeSize : (idx+1)*q.CodeSize] {
+				sum += weights[i] * float64(int8(c))
+			}
+			dots[idx] = sum
+		}
+
+	case ProductQuantization:
+		subDim := q.Dim / q.CodeSize
+		tables := make([][]float64, q.CodeSize)
+		for m, codebook := range q.Codebooks {
+			tables[m] = make([]float64, len(codebook)/subDim)
+			for c := range tables[m] {
+				tables[m][c] = dot64(vec[m*subDim:(m+1)*subDim], codebook[c*subDim:(c+1)*subDim])
+			}
+		}
+		for idx := range dots {
+			sum := 0.0
+			for m, c := range q.Codes[idx*q.CodeSize : (idx+1)*q.CodeSize] {
+				sum += tables[m][c]
+			}
+			dots[idx] = sum
+		}
+	}
+
+	norm := math.Sqrt(dot64(vec, vec))
+	for idx := range dots {
+		if length := norm * q.norms[idx]; length > 0 {
+			dots[idx] /= length
+		} else {
+			dots[idx] = math.Inf(-1)
+		}
+	}
+	return dots
+}
+
+func dot64(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// QuantizationReport compares the neighbours found with the quantized vectors to the float vectors
+type QuantizationReport struct {
+	Queries    int     // query words in the vocabulary
+	OOV        int     // query words skipped because they are out of vocabulary
+	Top        int     // neighbours compared per query
+	Recall     float64 // mean share of the float top neighbours found by the quantized search
+	Top1       float64 // share of queries with the same nearest neighbour
+	FloatBytes int     // size of the vectors as float32
+	CodeBytes  int     // size of the codes
+}
+
+func (r QuantizationReport) String() string {
+	return fmt.Sprintf("recall@%d %.4f, top-1 agreement %.4f, %d of %d bytes (%d queries, %d out of vocabulary)",
+		r.Top, r.Recall, r.Top1, r.CodeBytes, r.FloatBytes, r.Queries, r.OOV)
+}
+
+// EvaluateQuantization searches the top neighbours of every query word with its float vector in
+// the quantized model and in the float model. Use words held out from any tuning as queries.
+func EvaluateQuantization(queries []string, top int, q *QuantizedModel, model *Model) *QuantizationReport {
+	report := &QuantizationReport{
+		Top:        top,
+		FloatBytes: 4 * len(q.Words) * q.Dim,
+		CodeBytes:  len(q.Codes),
+	}
+
+	for _, word := range queries {
+		modelIdx, ok := lookupWord(model, word)
+		if !ok {
+			report.OOV++
+			continue
+		}
+		idx, ok := q.index[model.Vocab[modelIdx].Literal]
+		if !ok {
+			report.OOV++
+			continue
+		}
+		report.Queries++
+
+		exact, _ := MostSimilarWords([]string{model.Vocab[modelIdx].Literal}, nil, top, model)
+		approx := q.mostSimilar(model.Vocab[modelIdx].Vector.RawVector().Data, top, map[int]bool{idx: true})
+		if len(exact) == 0 {
+			continue
+		}
+
+		found := make(map[string]bool, len(approx))
+		for _, pair := range approx {
+			found[pair.Key] = true
+		}
+		hits := 0
+		for _, pair := range exact {
+			if found[pair.Key] {
+				hits++
+			}
+		}
+
+		report.Recall += float64(hits) / float64(len(exact))
+		if len(approx) > 0 && approx[0].Key == exact[0].Key {
+			report.Top1++
+		}
+	}
+
+	if report.Queries > 0 {
+		report.Recall /= float64(report.Queries)
+		report.Top1 /= float64(report.Queries)
+	}
+	return report
+}
+
+// The quantized format, all little-endian: a quantizedHeader, Size times a uint32 byte length
+// followed by the word, a uint32 byte length followed by the json tokenizer options (see
+// WriteVectorStore), the Dim scales or the Subspaces codebooks as float64 and the codes.
+
+const (
+	quantizedMagic   = "W2VQUANT"
+	quantizedVersion = 1
+)
+
+type quantizedHeader struct {
+	Magic        [8]byte
+	Version      uint32
+	Quantization uint32
+	Dim          uint32
+	Size         uint32
+	CodeSize     uint32
+	Centroids    uint32 // per subspace
+}
+
+// SaveQuantized writes the quantized model to path
+func SaveQuantized(q *QuantizedModel, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = writeQuantized(w, q)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func writeQuantized(w io.Writer, q *QuantizedModel) error {
+	header := quantizedHeader{
+		Version:      quantizedVersion,
+		Quantization: uint32(q.Quantization),
+		Dim:          uint32(q.Dim),
+		Size:         uint32(len(q.Words)),
+		CodeSize:     uint32(q.CodeSize),
+	}
+	copy(header.Magic[:], quantizedMagic)
+	if q.Quantization == ProductQuantization {
+		header.Centroids = uint32(len(q.Codebooks[0]) / (q.Dim / q.CodeSize))
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+
+	for _, word := range q.Words {
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(word))); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, word); err != nil {
+			return err
+		}
+	}
+
+	options, err := encodeTokenizer(q.tokenizer)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(options))); err != nil {
+		return err
+	}
+	if _, err := w.Write(options); err != nil {
+		return err
+	}
+
+	switch q.Quantization {
+	case ScalarQuantization:
+		if err := binary.Write(w, binary.LittleEndian, q.Scale); err != nil {
+			return err
+		}
+	case ProductQuantization:
+		for _, codebook := range q.Codebooks {
+			if err := binary.Write(w, binary.LittleEndian, codebook); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = w.Write(q.Codes)
+	return err
+}
+
+// LoadQuantized reads a quantized model written by SaveQuantized
+func LoadQuantized(path string) (*QuantizedModel, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return readQuantized(bufio.NewReader(f), info.Size())
+}
+
+// readQuantized reads a quantized model of fileSize bytes, sizes in the file are checked against
+// the bytes left before anything is allocated for them
+func readQuantized(r io.Reader, fileSize int64) (*QuantizedModel, error) {
+	var header quantizedHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	left := uint64(0)
+	if fileSize > int64(binary.Size(header)) {
+		left = uint64(fileSize) - uint64(binary.Size(header))
+	}
+	// take reserves n bytes of the rest of the file
+	take := func(n uint64) error {
+		if n > left {
+			return fmt.Errorf("word2vec: quantized model of %d bytes is truncated", fileSize)
+		}
+		left -= n
+		return nil
+	}
+
+	if string(header.Magic[:]) != quantizedMagic {
+		return nil, &HeaderError{Header: string(header.Magic[:])}
+	}
+	if header.Version != quantizedVersion {
+		return nil, fmt.Errorf("word2vec: unsupported quantized model version %d", header.Version)
+	}
+
+	scalar := Quantization(header.Quantization) == ScalarQuantization && header.CodeSize == header.Dim
+	product := Quantization(header.Quantization) == ProductQuantization && header.CodeSize > 0 &&
+		header.Dim%header.CodeSize == 0 && header.Centroids > 0 && header.Centroids <= pqCentroids
+	if !scalar && !product {
+		return nil, fmt.Errorf("word2vec: invalid quantized model with quantization %d, dimension %d and code size %d",
+			header.Quantization, header.Dim, header.CodeSize)
+	}
+
+	// a word takes at least its length and its code, the scales or codebooks 8 bytes per value
+	values := uint64(header.Dim)
+	if product {
+		values *= uint64(header.Centroids)
+	}
+	if uint64(header.Size) > left/(4+uint64(header.CodeSize)) || values > left/8 {
+		return nil, fmt.Errorf("word2vec: quantized model of %d bytes can not hold %d words of dimension %d",
+			fileSize, header.Size, header.Dim)
+	}
+
+	q := &QuantizedModel{
+		Quantization: Quantization(header.Quantization),
+		Dim:          int(header.Dim),
+		Words:        make([]string, header.Size),
+		CodeSize:     int(header.CodeSize),
+		index:        make(map[string]int, header.Size),
+	}
+
+	for idx := range q.Words {
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+		if err := take(4 + uint64(length)); err != nil {
+			return nil, err
+		}
+		word := make([]byte, length)
+		if _, err := io.ReadFull(r, word); err != nil {
+			return nil, err
+		}
+		q.Words[idx] = string(word)
+		q.index[q.Words[idx]] = idx
+	}
+
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	if err := take(4 + uint64(length)); err != nil {
+		return nil, err
+	}
+	options := make([]byte, length)
+	if _, err := io.ReadFull(r, options); err != nil {
+		return nil, err
+	}
+	tokenizer, err := decodeTokenizer(options)
+	if err != nil {
+		return nil, err
+	}
+	q.tokenizer = tokenizer
+
+	if err := take(8*values + uint64(header.Size)*uint64(header.CodeSize)); err != nil {
+		return nil, err
+	}
+	switch q.Quantization {
+	case ScalarQuantization:
+		q.Scale = make([]float64, q.Dim)
+		if err := binary.Read(r, binary.LittleEndian, q.Scale); err != nil {
+			return nil, err
+		}
+	case ProductQuantization:
+		subDim := q.Dim / q.CodeSize
+		q.Codebooks = make([][]float64, q.CodeSize)
+		for m := range q.Codebooks {
+			q.Codebooks[m] = make([]float64, int(header.Centroids)*subDim)
+			if err := binary.Read(r, binary.LittleEndian, q.Codebooks[m]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	q.Codes = make([]byte, len(q.Words)*q.CodeSize)
+	if _, err := io.ReadFull(r, q.Codes); err != nil {
+		return nil, err
+	}
+	if q.Quantization == ProductQuantization {
+		for _, c := range q.Codes {
+			if uint32(c) >= header.Centroids {
+				return nil, fmt.Errorf("word2vec: code %d out of %d centroids", c, header.Centroids)
+			}
+		}
+	}
+
+	q.initNorms()
+	return q, nil
+}
@@ -0,0 +1,181 @@
+package word2vec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuantizeScalar(t *testing.T) {
+	fmt.Println("TestQuantizeScalar")
+	model := randomModel(500, 16, 3)
+	q := QuantizeScalar(model)
+
+	if len(q.Codes) != 500*16 {
+		t.Fatalf("Got %d instead of %d code bytes", len(q.Codes), 500*16)
+	}
+
+	for _, phraseObj := range model.Vocab[:20] {
+		vec, err := q.Vector(phraseObj.Literal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range phraseObj.Vector.RawVector().Data {
+			if math.Abs(vec[i]-v) > q.Scale[i]/2+1e-9 {
+				t.Errorf("Got %f instead of %f for %s", vec[i], v, phraseObj.Literal)
+			}
+		}
+	}
+
+	queries := make([]string, 0, 50)
+	for _, phraseObj := range model.Vocab[:50] {
+		queries = append(queries, phraseObj.Literal)
+	}
+	report := EvaluateQuantization(append(queries, "banana"), 10, q, model)
+	if report.Queries != 50 || report.OOV != 1 {
+		t.Errorf("Got %d queries and %d out of vocabulary instead of 50 and 1", report.Queries, report.OOV)
+	}
+	if report.Recall < 0.9 {
+		t.Errorf("Got recall %f for int8 vectors: %s", report.Recall, report)
+	}
+}
+
+func TestQuantizeProduct(t *testing.T) {
+	fmt.Println("TestQuantizeProduct")
+	model := randomModel(500, 16, 3)
+
+	if _, err := QuantizeProduct(5, 10, model); err == nil {
+		t.Error("Expected an error for a dimension not divisible by the subspaces")
+	}
+
+	q, err := QuantizeProduct(4, 10, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.CodeSize != 4 || len(q.Codes) != 500*4 || len(q.Codebooks[0]) != 256*4 {
+		t.Fatalf("Got code size %d, %d code bytes and %d codebook values", q.CodeSize, len(q.Codes), len(q.Codebooks[0]))
+	}
+
+	again, err := QuantizeProduct(4, 10, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again.Codes) != string(q.Codes) {
+		t.Error("Expected the same codes for the same seed")
+	}
+
+	queries := make([]string, 0, 50)
+	for _, phraseObj := range model.Vocab[:50] {
+		queries = append(queries, phraseObj.Literal)
+	}
+	report := EvaluateQuantization(queries, 10, q, model)
+	if report.CodeBytes*16 != report.FloatBytes {
+		t.Errorf("Got %d instead of %d code bytes", report.CodeBytes, report.FloatBytes/16)
+	}
+	if report.Recall < 0.5 {
+		t.Errorf("Got recall %f for product codes: %s", report.Recall, report)
+	}
+
+	// the asymmetric similarity equals the cosine of the query and the reconstructed vector
+	query := model.Vocab[0].Vector.RawVector().Data
+	sims, err := q.MostSimilarByVector(query, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, _ := q.Vector(sims[0].Key)
+	expected := dot64(query, vec) / math.Sqrt(dot64(query, query)*dot64(vec, vec))
+	if math.Abs(sims[0].Sim-expected) > 1e-9 {
+		t.Errorf("Got similarity %f instead of %f", sims[0].Sim, expected)
+	}
+}
+
+func TestSaveLoadQuantized(t *testing.T) {
+	fmt.Println("TestSaveLoadQuantized")
+	model := randomModel(300, 8, 5)
+	model.Tokenizer = &UnicodeTokenizer{Lowercase: true, FoldAccents: true}
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	product, err := QuantizeProduct(2, 5, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range []*QuantizedModel{QuantizeScalar(model), product} {
+		path := filepath.Join(dir, fmt.Sprintf("model-%d.quant", q.Quantization))
+		if err := SaveQuantized(q, path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadQuantized(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.Len() != q.Len() || string(loaded.Codes) != string(q.Codes) {
+			t.Fatalf("Got %d words and different codes after loading quantization %d", loaded.Len(), q.Quantization)
+		}
+
+		// queries are tokenized by the tokenizer of the model, which drops the combining accent
+		if _, err := loaded.Vector("W\u03017"); err != nil {
+			t.Errorf("Query W\u03017 did not find w7 after loading quantization %d: %v", q.Quantization, err)
+		}
+
+		expected, _ := q.MostSimilar("w7", 5)
+		got, err := loaded.MostSimilar("w7", 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("Got %v instead of %v after loading quantization %d", got, expected, q.Quantization)
+				break
+			}
+		}
+	}
+
+	path := filepath.Join(dir, "model.txt")
+	if err := SaveFormat(model, path, Text); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadQuantized(path); err == nil {
+		t.Error("Expected an error for a file without the quantized header")
+	}
+}
+
+func TestLoadQuantizedCorrupt(t *testing.T) {
+	fmt.Println("TestLoadQuantizedCorrupt")
+	corrupt := func(header quantizedHeader, rest ...uint32) []byte {
+		copy(header.Magic[:], quantizedMagic)
+		header.Version = quantizedVersion
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, header)
+		binary.Write(&buf, binary.LittleEndian, rest)
+		for buf.Len() < 64 {
+			buf.WriteByte(0)
+		}
+		return buf.Bytes()
+	}
+
+	// headers and lengths announcing more than the file holds fail before allocating them
+	cases := map[string][]byte{
+		"size":             corrupt(quantizedHeader{Dim: 4, CodeSize: 4, Size: 1 << 30}),
+		"dimension":        corrupt(quantizedHeader{Dim: 0xffffffff, CodeSize: 0xffffffff}),
+		"codebooks":        corrupt(quantizedHeader{Quantization: uint32(ProductQuantization), Dim: 1 << 31, CodeSize: 1, Centroids: 256}),
+		"word length":      corrupt(quantizedHeader{Dim: 1, CodeSize: 1, Size: 1}, 0xffffffff),
+		"tokenizer length": corrupt(quantizedHeader{Dim: 1, CodeSize: 1}, 0xffffffff),
+	}
+	for name, data := range cases {
+		if _, err := readQuantized(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Errorf("Expected an error for a quantized model with a corrupt %s", name)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package word2vec
+
+import (
+	"container/heap"
+	"errors"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// WeightedWord is a query word with its weight, positive words usually have
+// weight 1 and negative words weight -1
+type WeightedWord struct {
+	Word   string
+	Weight float64
+}
+
+// MostSimilarWords returns the top words closest to the positive and farthest from the
+// negative words, e.g. positive king and woman, negative man for "king - man + woman".
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/keyedvectors.py (most_similar)
+func MostSimilarWords(positive []string, negative []string, top int, model *Model) ([]SimPair, error) {
+	terms := make([]WeightedWord, 0, len(positive)+len(negative))
+	for _, word := range positive {
+		terms = append(terms, WeightedWord{Word: word, Weight: 1.0})
+	}
+	for _, word := range negative {
+		terms = append(terms, WeightedWord{Word: word, Weight: -1.0})
+	}
+	return MostSimilarWeighted(terms, top, model)
+}
+
+// MostSimilarWeighted returns the top words by cosine similarity to the weighted mean of
+// the unit vectors of terms. The query words themselves are never part of the result,
+// words out of vocabulary give an UnknownWordError.
+func MostSimilarWeighted(terms []WeightedWord, top int, model *Model) ([]SimPair, error) {
+	if len(terms) == 0 {
+		return nil, errors.New("word2vec: empty query")
+	}
+
+	norm := normalizedVectors(model)
+	query := mat64.NewVector(model.VecDim, nil)
+	exclude := make(map[int]bool)
+
+	for _, term := range terms {
+		idx, ok := lookupWord(model, term.Word)
+		if !ok {
+			return nil, &UnknownWordError{Word: term.Word}
+		}
+
+		query.AddScaledVec(query, term.Weight, norm.RowView(idx))
+		exclude[idx] = true
+	}
+
+	if length := mat64.Norm(query, 2); length > 0 {
+		query.ScaleVec(1/length, query)
+	}
+
+	sims := mat64.NewVector(len(model.Vocab), nil)
+	sims.MulVec(norm, query)
+
+	return topSimilar(sims.RawVector().Data, top, exclude, model), nil
+}
+
+// InitSims stores the vectors of the vocab scaled to unit length in model.Syn0Norm,
+// one row per word. Train and the loaders call it, call it again after changing Syn0.
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/keyedvectors.py (init_sims)
+func InitSims(model *Model) *Model {
+	model.normMu.Lock()
+	defer model.normMu.Unlock()
+
+	return initSims(model)
+}
+
+func initSims(model *Model) *Model {
+	if len(model.Vocab) == 0 {
+		model.Syn0Norm = nil
+		return model
+	}
+
+	norm := mat64.NewDense(len(model.Vocab), model.VecDim, nil)
+	for index, phraseObj := range model.Vocab {
+		row := norm.RowView(index)
+		row.CopyVec(phraseObj.Vector)
+
+		if length := mat64.Norm(row, 2); length > 0 {
+			row.ScaleVec(1/length, row)
+		}
+	}
+
+	model.Syn0Norm = norm
+	return model
+}
+
+// normalizedVectors returns model.Syn0Norm and computes it first if needed,
+// concurrent queries compute it only once
+func normalizedVectors(model *Model) *mat64.Dense {
+	model.normMu.Lock()
+	defer model.normMu.Unlock()
+
+	if model.Syn0Norm == nil {
+		initSims(model)
+	} else if r, _ := model.Syn0Norm.Dims(); r != len(model.Vocab) {
+		initSims(model)
+	}
+	return model.Syn0Norm
+}
+
+// WordSimilarity returns the cosine similarity of two words from the precomputed unit vectors
+func WordSimilarity(word1 string, word2 string, model *Model) (float64, error) {
+	idx1, ok := lookupWord(model, word1)
+	if !ok {
+		return 0, &UnknownWordError{Word: word1}
+	}
+	idx2, ok := lookupWord(model, word2)
+	if !ok {
+		return 0, &UnknownWordError{Word: word2}
+	}
+
+	norm := normalizedVectors(model)
+	return mat64.Dot(norm.RowView(idx1), norm.RowView(idx2)), nil
+}
+
+// MostSimilarBatch returns the top words for every row of queries. All queries are
+// scored with a single matrix product against Syn0Norm, which needs memory for
+// rows(queries) * vocab size similarities, so split very large batches.
+func MostSimilarBatch(queries *mat64.Dense, top int, model *Model) ([][]SimPair, error) {
+	n, dim := queries.Dims()
+	if dim != model.VecDim {
+		return nil, &DimensionError{Word: "query", Expected: model.VecDim, Got: dim}
+	}
+
+	res := make([][]SimPair, n)
+	if len(model.Vocab) == 0 {
+		return res, nil
+	}
+
+	q := mat64.DenseCopyOf(queries)
+	for i := 0; i < n; i++ {
+		row := q.RowView(i)
+		if length := mat64.Norm(row, 2); length > 0 {
+			row.ScaleVec(1/length, row)
+		}
+	}
+
+	sims := mat64.NewDense(n, len(model.Vocab), nil)
+	sims.Mul(q, normalizedVectors(model).T())
+
+	for i := 0; i < n; i++ {
+		res[i] = topSimilar(sims.RawRowView(i), top, nil, model)
+	}
+	return res, nil
+}
+
+// topSimilar selects the top words from the similarities to the whole vocab with a bounded heap
+func topSimilar(sims []float64, top int, exclude map[int]bool, model *Model) []SimPair {
+	return topSimilarLabels(sims, top, exclude, func(idx int) string { return model.Vocab[idx].Literal })
+}
+
+// topSimilarLabels is topSimilar for any list of vectors, label names the result at an index
+func topSimilarLabels(sims []float64, top int, exclude map[int]bool, label func(int) string) []SimPair {
+	if top <= 0 {
+		return []SimPair{}
+	}
+
+	results := make(farthestHeap, 0, top+1)
+	for idx, sim := range sims {
+		if exclude[idx] {
+			continue
+		}
+
+		if results.Len() < top || 1-sim < results[0].dist {
+			heap.Push(&results, hnswItem{idx, 1 - sim})
+			if results.Len() > top {
+				heap.Pop(&results)
+			}
+		}
+	}
+
+	res := make([]SimPair, results.Len())
+	for i := len(res) - 1; i >= 0; i-- {
+		item := heap.Pop(&results).(hnswItem)
+		res[i] = SimPair{label(item.id), 1 - item.dist}
+	}
+	return res
+}
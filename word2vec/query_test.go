@@ -0,0 +1,154 @@
+package word2vec
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestMostSimilarWords(t *testing.T) {
+	fmt.Println("TestMostSimilarWords")
+	model := analogyModel()
+
+	// queen ≈ king − man + woman
+	ans, err := MostSimilarWords([]string{"king", "woman"}, []string{"man"}, 2, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ans) != 2 {
+		t.Fatalf("Got %d instead of 2 results", len(ans))
+	}
+	if ans[0].Key != "queen" {
+		t.Errorf("Got %s instead of queen", ans[0].Key)
+	}
+	for _, pair := range ans {
+		if pair.Key == "king" || pair.Key == "woman" || pair.Key == "man" {
+			t.Errorf("Query word %s is part of the result", pair.Key)
+		}
+	}
+}
+
+func TestMostSimilarWeighted(t *testing.T) {
+	fmt.Println("TestMostSimilarWeighted")
+	model := analogyModel()
+
+	ans, err := MostSimilarWeighted([]WeightedWord{{"apple", 1.0}, {"man", 0.1}}, 10, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ans) != 4 || ans[0].Key != "pear" {
+		t.Errorf("Got %v, expected pear first out of 4 words", ans)
+	}
+
+	_, err = MostSimilarWords([]string{"king", "unicorn"}, nil, 10, model)
+	if e, ok := err.(*UnknownWordError); !ok || e.Word != "unicorn" {
+		t.Errorf("Got %v instead of *UnknownWordError for unicorn", err)
+	}
+}
+
+func TestInitSims(t *testing.T) {
+	fmt.Println("TestInitSims")
+	model := analogyModel()
+
+	if model.Syn0Norm == nil {
+		t.Fatalf("Syn0Norm not populated after loading")
+	}
+
+	for idx := range model.Vocab {
+		if length := mat64.Norm(model.Syn0Norm.RowView(idx), 2); math.Abs(length-1) > 1e-9 {
+			t.Errorf("Got length %f instead of 1 for %s", length, model.Vocab[idx].Literal)
+		}
+	}
+
+	sim, err := WordSimilarity("king", "queen", model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Similarity(model.Vocab[0].Vector, model.Vocab[1].Vector, model)
+	if math.Abs(sim-expected) > 1e-9 {
+		t.Errorf("Got %f instead of %f", sim, expected)
+	}
+
+	if _, err := WordSimilarity("king", "unicorn", model); err == nil {
+		t.Errorf("Expected an error for an unknown word")
+	}
+}
+
+func TestMostSimilarBatch(t *testing.T) {
+	fmt.Println("TestMostSimilarBatch")
+	model := randomModel(300, 8, 3)
+
+	queries := mat64.NewDense(3, 8, nil)
+	for i, word := range []string{"w0", "w10", "w20"} {
+		queries.SetRow(i, model.Vocab[model.Word2Index[word]].Vector.RawVector().Data)
+	}
+
+	batch, err := MostSimilarBatch(queries, 5, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range batch {
+		expected := MostSimilarByVector(queries.RowView(i), 5, model)
+		for rank := range batch[i] {
+			if batch[i][rank].Key != expected[rank].Key || math.Abs(batch[i][rank].Sim-expected[rank].Sim) > 1e-9 {
+				t.Errorf("Got %v instead of %v for query %d at rank %d", batch[i][rank], expected[rank], i, rank)
+			}
+		}
+	}
+
+	if _, err := MostSimilarBatch(mat64.NewDense(1, 3, nil), 5, model); err == nil {
+		t.Errorf("Expected an error for queries of the wrong dimension")
+	}
+}
+
+func TestMostSimilarNoResults(t *testing.T) {
+	fmt.Println("TestMostSimilarNoResults")
+	model := analogyModel()
+
+	for _, top := range []int{0, -1} {
+		ans, err := MostSimilarWords([]string{"king"}, nil, top, model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ans) != 0 {
+			t.Errorf("Got %v for top %d", ans, top)
+		}
+
+		batch, err := MostSimilarBatch(mat64.NewDense(2, model.VecDim, nil), top, model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range batch {
+			if len(res) != 0 {
+				t.Errorf("Got %v in a batch for top %d", res, top)
+			}
+		}
+	}
+}
+
+func TestNormalizedVectorsConcurrent(t *testing.T) {
+	fmt.Println("TestNormalizedVectorsConcurrent")
+	model := randomModel(200, 8, 4)
+	model.Syn0Norm = nil
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := MostSimilarWords([]string{fmt.Sprintf("w%d", i)}, nil, 3, model); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if r, _ := model.Syn0Norm.Dims(); r != 200 {
+		t.Errorf("Got %d instead of 200 normalized rows", r)
+	}
+}
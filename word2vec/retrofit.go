@@ -0,0 +1,125 @@
+package word2vec
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Retrofitting pulls trained vectors toward their neighbours in a lexicon (synonyms, a glossary,
+// WordNet relations) while keeping them close to the original vectors, without retraining.
+// after Faruqui et al.: https://arxiv.org/abs/1411.4166
+// python: https://github.com/mfaruqui/retrofitting
+
+// Lexicon maps a word to its lexical neighbours
+type Lexicon map[string][]string
+
+// ReadLexicon reads a lexicon file with a word followed by its neighbours on every line,
+// e.g. "car automobile auto". Lines of the same word are merged.
+func ReadLexicon(path string) (Lexicon, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ReadLexiconReader(f)
+}
+
+// ReadLexiconReader reads a lexicon from r
+func ReadLexiconReader(r io.Reader) (Lexicon, error) {
+	lexicon := make(Lexicon)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		words := strings.Fields(s.Text())
+		if len(words) < 2 {
+			continue
+		}
+		lexicon[words[0]] = append(lexicon[words[0]], words[1:]...)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return lexicon, nil
+}
+
+// Retrofit returns a new model with the vectors of the model retrofitted to the lexicon. Every
+// iteration moves each word with neighbours in the vocabulary to
+//
+//	q_i = (n_i * v_i + sum_j q_j) / (2 * n_i)
+//
+// the mean of its original vector v_i and its n_i neighbours q_j, which is alpha = 1 and
+// beta = 1 / n_i in the paper. The paper uses 10 iterations. Edges are directed as in the lexicon,
+// list a word on the lines of both words for a symmetric relation. The model is not changed.
+func Retrofit(lexicon Lexicon, iterations int, model *Model) *Model {
+	dim := model.VecDim
+	data := make([]float64, len(model.Vocab)*dim)
+	for idx, phraseObj := range model.Vocab {
+		copy(data[idx*dim:(idx+1)*dim], phraseObj.Vector.RawVector().Data)
+	}
+
+	// resolve the lexicon to indices once, in a fixed order so the result does not depend on map iteration
+	words := make([]string, 0, len(lexicon))
+	for word := range lexicon {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	type lexiconEntry struct {
+		idx        int
+		neighbours []int
+	}
+	entries := make([]lexiconEntry, 0, len(words))
+	for _, word := range words {
+		idx, ok := lookupWord(model, word)
+		if !ok {
+			continue
+		}
+
+		neighbours := make([]int, 0, len(lexicon[word]))
+		for _, neighbour := range lexicon[word] {
+			if neighbourIdx, ok := lookupWord(model, neighbour); ok && neighbourIdx != idx {
+				neighbours = append(neighbours, neighbourIdx)
+			}
+		}
+		if len(neighbours) > 0 {
+			entries = append(entries, lexiconEntry{idx, neighbours})
+		}
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+		for _, entry := range entries {
+			n := float64(len(entry.neighbours))
+			original := model.Vocab[entry.idx].Vector.RawVector().Data
+			vec := data[entry.idx*dim : (entry.idx+1)*dim]
+
+			for i := range vec {
+				vec[i] = n * original[i]
+			}
+			for _, neighbour := range entry.neighbours {
+				for i, v := range data[neighbour*dim : (neighbour+1)*dim] {
+					vec[i] += v
+				}
+			}
+			for i := range vec {
+				vec[i] /= 2 * n
+			}
+		}
+	}
+
+	return newDerivedModel(model, data)
+}
+
+// RetrofitFile retrofits the vectors of the model to the lexicon file at path
+func RetrofitFile(path string, iterations int, model *Model) (*Model, error) {
+	lexicon, err := ReadLexicon(path)
+	if err != nil {
+		return nil, err
+	}
+	return Retrofit(lexicon, iterations, model), nil
+}
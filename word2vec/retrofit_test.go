@@ -0,0 +1,98 @@
+package word2vec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRetrofit(t *testing.T) {
+	fmt.Println("TestRetrofit")
+	model := analogyModel()
+	before, _ := WordSimilarity("apple", "king", model)
+
+	lexicon := Lexicon{"apple": {"king", "banana"}, "Pear": {"pear"}}
+	retrofitted := Retrofit(lexicon, 1, model)
+
+	apple := model.Vocab[model.Word2Index["apple"]].Vector
+	king := model.Vocab[model.Word2Index["king"]].Vector
+	got := retrofitted.Vocab[retrofitted.Word2Index["apple"]].Vector
+	for i := 0; i < model.VecDim; i++ {
+		if expected := (apple.At(i, 0) + king.At(i, 0)) / 2; math.Abs(got.At(i, 0)-expected) > 1e-12 {
+			t.Errorf("Got %f instead of %f in dimension %d of apple", got.At(i, 0), expected, i)
+		}
+	}
+
+	after, _ := WordSimilarity("apple", "king", retrofitted)
+	if after <= before {
+		t.Errorf("Got similarity %f after retrofitting, not more than %f", after, before)
+	}
+
+	for _, word := range []string{"queen", "pear", "king"} {
+		original := model.Vocab[model.Word2Index[word]]
+		phraseObj := retrofitted.Vocab[retrofitted.Word2Index[word]]
+		if phraseObj.Count != original.Count {
+			t.Errorf("Got count %d instead of %d for %s", phraseObj.Count, original.Count, word)
+		}
+		for i := 0; i < model.VecDim; i++ {
+			if phraseObj.Vector.At(i, 0) != original.Vector.At(i, 0) {
+				t.Errorf("Expected %s without neighbours in the vocabulary to keep its vector", word)
+				break
+			}
+		}
+	}
+
+	if model.Vocab[model.Word2Index["apple"]].Vector.At(0, 0) != apple.At(0, 0) {
+		t.Error("Expected the original model to be unchanged")
+	}
+}
+
+func TestRetrofitConverges(t *testing.T) {
+	fmt.Println("TestRetrofitConverges")
+	model := randomModel(50, 8, 21)
+	lexicon := Lexicon{"w1": {"w2"}, "w2": {"w1"}}
+
+	// q1 = (v1 + q2) / 2 and q2 = (v2 + q1) / 2 converge to q1 = (2 v1 + v2) / 3
+	retrofitted := Retrofit(lexicon, 50, model)
+	v1 := model.Vocab[model.Word2Index["w1"]].Vector
+	v2 := model.Vocab[model.Word2Index["w2"]].Vector
+	q1 := retrofitted.Vocab[retrofitted.Word2Index["w1"]].Vector
+	for i := 0; i < model.VecDim; i++ {
+		if expected := (2*v1.At(i, 0) + v2.At(i, 0)) / 3; math.Abs(q1.At(i, 0)-expected) > 1e-9 {
+			t.Errorf("Got %f instead of %f in dimension %d", q1.At(i, 0), expected, i)
+		}
+	}
+}
+
+func TestReadLexicon(t *testing.T) {
+	fmt.Println("TestReadLexicon")
+	lexicon, err := ReadLexiconReader(strings.NewReader("car automobile auto\nlonely\n\ncar vehicle\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lexicon) != 1 || strings.Join(lexicon["car"], " ") != "automobile auto vehicle" {
+		t.Errorf("Got %v", lexicon)
+	}
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lexicon.txt")
+	if err := ioutil.WriteFile(path, []byte("apple pear\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	retrofitted, err := RetrofitFile(path, 10, analogyModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim, _ := WordSimilarity("apple", "pear", retrofitted); sim < 0.99 {
+		t.Errorf("Got similarity %f of apple and pear after retrofitting", sim)
+	}
+}
@@ -0,0 +1,114 @@
+package word2vec
+
+import (
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Subword embeddings after Bojanowski et al. (fastText): https://arxiv.org/abs/1607.04606
+// A word is represented by its own vector plus the vectors of its character n-grams,
+// e.g. "<wh", "whe", "her", "ere", "re>" for "where" and n = 3. The n-grams are hashed
+// into Buckets rows of Syn0Ngrams, so misspelled and unseen words still get a vector.
+// Subwords are used when model.Buckets > 0.
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/fasttext.py
+
+// ngramBuckets returns the Syn0Ngrams rows of all n-grams of "<word>" with MinN <= n <= MaxN
+func ngramBuckets(word string, model *Model) []int {
+	runes := []rune("<" + word + ">")
+	buckets := make([]int, 0)
+
+	for n := model.MinN; n <= model.MaxN; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			buckets = append(buckets, int(hash(string(runes[i:i+n]))%uint32(model.Buckets)))
+		}
+	}
+	return buckets
+}
+
+// resetNgramWeights keeps the word-only rows in Syn0Vocab, initializes the n-gram rows
+// like gensim and assigns the n-grams of every word
+func resetNgramWeights(model *Model) *Model {
+	model.Syn0Vocab = mat64.DenseCopyOf(model.Syn0)
+	model.Syn0Ngrams = mat64.NewDense(model.Buckets, model.VecDim, nil)
+
+	r := rand.New(rand.NewSource(model.Seed))
+	data := model.Syn0Ngrams.RawMatrix().Data
+	for i := range data {
+		data[i] = (r.Float64() - 0.5) * 2 / float64(model.VecDim)
+	}
+
+	for _, phraseObj := range model.Vocab {
+		phraseObj.Ngrams = ngramBuckets(phraseObj.Literal, model)
+	}
+
+	return model
+}
+
+// inputVector returns the input (projection) layer of word: its Syn0 row, or with
+// subwords the mean of its word row and n-gram rows
+func inputVector(model *Model, word *Phrase) *mat64.Vector {
+	if model.Buckets <= 0 {
+		return model.Syn0.RowView(word.Id)
+	}
+
+	l1 := mat64.NewVector(model.VecDim, nil)
+	l1.CopyVec(model.Syn0Vocab.RowView(word.Id))
+	for _, bucket := range word.Ngrams {
+		l1.AddVec(l1, model.Syn0Ngrams.RowView(bucket))
+	}
+	l1.ScaleVec(1.0/float64(1+len(word.Ngrams)), l1)
+	return l1
+}
+
+// updateInput adds the error neu1e to the input layer of word,
+// with subwords it is spread over the word row and the n-gram rows
+func updateInput(model *Model, word *Phrase, neu1e *mat64.Vector) {
+	if model.Buckets <= 0 {
+		l1 := model.Syn0.RowView(word.Id)
+		l1.AddVec(l1, neu1e)
+		return
+	}
+
+	scale := 1.0 / float64(1+len(word.Ngrams))
+
+	l1 := model.Syn0Vocab.RowView(word.Id)
+	l1.AddScaledVec(l1, scale, neu1e)
+	for _, bucket := range word.Ngrams {
+		row := model.Syn0Ngrams.RowView(bucket)
+		row.AddScaledVec(row, scale, neu1e)
+	}
+}
+
+// adjustVectors sets Syn0 to the final vectors of the subword model, the mean of
+// the word row and the n-gram rows, so they compare with vectors from WordVector
+func adjustVectors(model *Model) *Model {
+	for _, phraseObj := range model.Vocab {
+		model.Syn0.SetRow(phraseObj.Id, inputVector(model, phraseObj).RawVector().Data)
+	}
+	return model
+}
+
+// WordVector returns the vector of word. Words out of vocabulary get the mean of their
+// n-gram vectors if the model was trained with subwords, otherwise an UnknownWordError.
+func WordVector(word string, model *Model) (*mat64.Vector, error) {
+	if idx, ok := lookupWord(model, word); ok {
+		return model.Vocab[idx].Vector, nil
+	}
+
+	if model.Buckets <= 0 || model.Syn0Ngrams == nil {
+		return nil, &UnknownWordError{Word: word}
+	}
+
+	buckets := ngramBuckets(vocabForm(model, word), model)
+	if len(buckets) == 0 {
+		return nil, &UnknownWordError{Word: word}
+	}
+
+	vec := mat64.NewVector(model.VecDim, nil)
+	for _, bucket := range buckets {
+		vec.AddVec(vec, model.Syn0Ngrams.RowView(bucket))
+	}
+	vec.ScaleVec(1.0/float64(len(buckets)), vec)
+	return vec, nil
+}
@@ -0,0 +1,123 @@
+package word2vec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func subwordModel(t *testing.T, architecture Architecture) *Model {
+	config := testConfig(2)
+	config.Architecture = architecture
+	config.Buckets = 1000
+	return testModel(t, config, tinySentences)
+}
+
+func TestNgramBuckets(t *testing.T) {
+	fmt.Println("TestNgramBuckets")
+	model := newModel(1, 1, 10)
+	model.MinN = 3
+	model.MaxN = 4
+	model.Buckets = 100
+
+	// "<año>" has 3 trigrams and 2 four-grams when counted in runes
+	buckets := ngramBuckets("año", model)
+	if len(buckets) != 5 {
+		t.Errorf("Got %d instead of 5 n-grams", len(buckets))
+	}
+	for _, bucket := range buckets {
+		if bucket < 0 || bucket >= model.Buckets {
+			t.Errorf("Bucket %d out of range", bucket)
+		}
+	}
+}
+
+func TestTrainSubwords(t *testing.T) {
+	fmt.Println("TestTrainSubwords")
+	for _, architecture := range []Architecture{SkipGram, CBOW} {
+		model := subwordModel(t, architecture)
+		if len(model.Vocab[model.Word2Index["want"]].Ngrams) == 0 {
+			t.Fatalf("No n-grams assigned to want")
+		}
+
+		before := mat64.DenseCopyOf(model.Syn0Ngrams)
+		model = Train(tinySentences, model)
+
+		if mat64.Equal(before, model.Syn0Ngrams) {
+			t.Errorf("Training did not update the n-gram vectors")
+		}
+
+		want := model.Vocab[model.Word2Index["want"]]
+		if !mat64.EqualApprox(want.Vector, inputVector(model, want), 1e-12) {
+			t.Errorf("Word vector is not the mean of its word and n-gram rows")
+		}
+	}
+}
+
+func TestWordVector(t *testing.T) {
+	fmt.Println("TestWordVector")
+	model := subwordModel(t, SkipGram)
+	model = Train(tinySentences, model)
+
+	vec, err := WordVector("want", model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vec != model.Vocab[model.Word2Index["want"]].Vector {
+		t.Errorf("In-vocabulary word did not return its own vector")
+	}
+
+	// misspelled word shares n-grams with "want"
+	vec, err = WordVector("wantt", model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vec.Len() != model.VecDim {
+		t.Errorf("Got vector of length %d instead of %d", vec.Len(), model.VecDim)
+	}
+
+	model.Buckets = 0
+	if _, err := WordVector("wantt", model); err == nil {
+		t.Errorf("Expected UnknownWordError without subwords")
+	} else if _, ok := err.(*UnknownWordError); !ok {
+		t.Errorf("Got %T instead of *UnknownWordError", err)
+	}
+}
+
+func TestCheckpointSubwords(t *testing.T) {
+	fmt.Println("TestCheckpointSubwords")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	model := subwordModel(t, SkipGram)
+	model = Train(tinySentences, model)
+
+	path := filepath.Join(dir, "checkpoint.gob")
+	if err := SaveCheckpoint(model, path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Buckets != model.Buckets || !mat64.Equal(restored.Syn0Ngrams, model.Syn0Ngrams) {
+		t.Errorf("N-gram vectors were not restored")
+	}
+
+	expected, _ := WordVector("wantt", model)
+	got, err := WordVector("wantt", restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat64.Equal(expected, got) {
+		t.Errorf("Out-of-vocabulary vector differs after loading the checkpoint")
+	}
+}
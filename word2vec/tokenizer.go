@@ -0,0 +1,286 @@
+package word2vec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer splits raw text into the tokens stored in the vocabulary. The model applies it to
+// every element of a sentence in BuildVocab, Train and UpdateVocab and to query words,
+// so training and queries see the same forms.
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// UnicodeTokenizer is the default Tokenizer. Tokens are runs of letters, digits, combining marks
+// and "_" (which joins detected phrases). An apostrophe between letters ("don't") and a hyphen
+// between letters or digits ("well-known") stay inside the token, as do "." and "," between
+// digits ("3.14", "1,000"). Soft hyphens (U+00AD) are dropped, everything else separates tokens.
+// Tokenizing tokens again gives the same tokens, NumberToken and URLToken are kept as they are,
+// also inside phrases ("route_<num>"), so a PhraseCorpus can be passed to BuildVocabCorpus.
+type UnicodeTokenizer struct {
+	Lowercase    bool
+	FoldAccents  bool   // "café" -> "cafe", note that "año" -> "ano"
+	SplitHyphens bool   // "well-known" -> "well", "known"
+	NumberToken  string // replaces numbers like "42", "3.14" or "1,000" if not empty
+	URLToken     string // replaces http://, https:// and www. links if not empty
+}
+
+// DefaultTokenizer returns the tokenizer of models without one, it only lowercases
+func DefaultTokenizer() *UnicodeTokenizer {
+	return &UnicodeTokenizer{Lowercase: true}
+}
+
+// Tokenize splits text into tokens
+func (t *UnicodeTokenizer) Tokenize(text string) []string {
+	tokens := make([]string, 0)
+
+	for _, field := range strings.Fields(text) {
+		if t.URLToken != "" && isURL(field) {
+			tokens = append(tokens, t.URLToken)
+			continue
+		}
+
+		field = markPlaceholder(markPlaceholder(field, t.NumberToken, numberMark), t.URLToken, urlMark)
+		for _, token := range splitWords(field, t.SplitHyphens) {
+			if t.NumberToken != "" && isNumber(token) {
+				tokens = append(tokens, t.NumberToken)
+				continue
+			}
+
+			if t.Lowercase {
+				token = strings.ToLower(token)
+			}
+			if t.FoldAccents {
+				token = foldAccents(token)
+			}
+			if strings.ContainsAny(token, placeholderMarks) {
+				token = strings.NewReplacer(string(numberMark), t.NumberToken, string(urlMark), t.URLToken).Replace(token)
+			}
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+// numberMark and urlMark stand in for NumberToken and URLToken in the input while it is split,
+// private use runes are word runes which the case mapping and accent folding leave alone
+const (
+	numberMark       = '\uE000'
+	urlMark          = '\uE001'
+	placeholderMarks = string(numberMark) + string(urlMark)
+)
+
+// markPlaceholder replaces the occurrences of placeholder in field by mark, unless they
+// are part of a longer word: "<num>_km" is marked, "NUMBER" for placeholder "NUM" is not
+func markPlaceholder(field string, placeholder string, mark rune) string {
+	if placeholder == "" || !strings.Contains(field, placeholder) {
+		return field
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(field, placeholder)
+		if i < 0 {
+			break
+		}
+		end := i + len(placeholder)
+
+		before, _ := utf8.DecodeLastRuneInString(field[:i])
+		after, _ := utf8.DecodeRuneInString(field[end:])
+		if (i == 0 || !isWordRune(before) || before == '_') && (end == len(field) || !isWordRune(after) || after == '_') {
+			b.WriteString(field[:i])
+			b.WriteRune(mark)
+		} else {
+			b.WriteString(field[:end])
+		}
+		field = field[end:]
+	}
+	b.WriteString(field)
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_' || r == numberMark || r == urlMark
+}
+
+// splitWords splits a whitespace free field into tokens by the rules of UnicodeTokenizer
+func splitWords(field string, splitHyphens bool) []string {
+	runes := make([]rune, 0, len(field))
+	for _, r := range field {
+		if r != '\u00ad' {
+			runes = append(runes, r)
+		}
+	}
+
+	words := make([]string, 0, 1)
+	start := -1
+	for i, r := range runes {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 && i+1 < len(runes) && isConnector(runes[i-1], r, runes[i+1], splitHyphens) {
+			continue
+		}
+
+		if start >= 0 {
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// isConnector reports whether r between prev and next belongs to the token
+func isConnector(prev rune, r rune, next rune, splitHyphens bool) bool {
+	switch r {
+	case '\'', '\u2019':
+		return unicode.IsLetter(prev) && unicode.IsLetter(next)
+	case '-', '\u2010':
+		return !splitHyphens && isWordRune(prev) && isWordRune(next)
+	case '.', ',':
+		return unicode.IsDigit(prev) && unicode.IsDigit(next)
+	}
+	return false
+}
+
+func isURL(field string) bool {
+	lower := strings.ToLower(field)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "www.")
+}
+
+func isNumber(token string) bool {
+	digits := false
+	for _, r := range token {
+		switch {
+		case unicode.IsDigit(r):
+			digits = true
+		case r != '.' && r != ',':
+			return false
+		}
+	}
+	return digits
+}
+
+// accentFolds maps accented Latin letters to their base letter
+var accentFolds = makeAccentFolds(map[rune]string{
+	'a': "àáâãäåāăą", 'A': "ÀÁÂÃÄÅĀĂĄ",
+	'c': "çćĉċč", 'C': "ÇĆĈĊČ",
+	'd': "ď", 'D': "Ď",
+	'e': "èéêëēĕėęě", 'E': "ÈÉÊËĒĔĖĘĚ",
+	'g': "ĝğġģ", 'G': "ĜĞĠĢ",
+	'i': "ìíîïĩīĭį", 'I': "ÌÍÎÏĨĪĬĮİ",
+	'l': "ĺļľł", 'L': "ĹĻĽŁ",
+	'n': "ñńņň", 'N': "ÑŃŅŇ",
+	'o': "òóôõöøōŏő", 'O': "ÒÓÔÕÖØŌŎŐ",
+	'r': "ŕŗř", 'R': "ŔŖŘ",
+	's': "śŝşš", 'S': "ŚŜŞŠ",
+	't': "ţť", 'T': "ŢŤ",
+	'u': "ùúûüũūŭůűų", 'U': "ÙÚÛÜŨŪŬŮŰŲ",
+	'y': "ýÿŷ", 'Y': "ÝŸŶ",
+	'z': "źżž", 'Z': "ŹŻŽ",
+})
+
+func makeAccentFolds(bases map[rune]string) map[rune]rune {
+	folds := make(map[rune]rune)
+	for base, accented := range bases {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	return folds
+}
+
+// foldAccents replaces accented Latin letters by their base letter and drops combining marks
+func foldAccents(token string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		if base, ok := accentFolds[r]; ok {
+			return base
+		}
+		return r
+	}, token)
+}
+
+// modelTokenizer returns the tokenizer of the model, DefaultTokenizer if it has none
+func modelTokenizer(model *Model) Tokenizer {
+	if model.Tokenizer == nil {
+		return DefaultTokenizer()
+	}
+	return model.Tokenizer
+}
+
+// savedTokenizer returns the options stored with the vectors of a model, nil for DefaultTokenizer
+// and for tokenizers other than UnicodeTokenizer, which load with DefaultTokenizer
+func savedTokenizer(tokenizer Tokenizer) *UnicodeTokenizer {
+	if t, ok := tokenizer.(*UnicodeTokenizer); ok {
+		return t
+	}
+	return nil
+}
+
+// loadedTokenizer returns the Tokenizer of saved options, nil (DefaultTokenizer) without options
+func loadedTokenizer(t *UnicodeTokenizer) Tokenizer {
+	if t == nil {
+		return nil
+	}
+	return t
+}
+
+// encodeTokenizer returns the saved options of tokenizer as json, "null" for DefaultTokenizer
+func encodeTokenizer(tokenizer Tokenizer) ([]byte, error) {
+	return json.Marshal(savedTokenizer(tokenizer))
+}
+
+// decodeTokenizer returns the tokenizer of options written by encodeTokenizer
+func decodeTokenizer(b []byte) (Tokenizer, error) {
+	var t *UnicodeTokenizer
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("word2vec: invalid tokenizer options: %v", err)
+	}
+	return loadedTokenizer(t), nil
+}
+
+// sentenceTokens tokenizes every element of sentence
+func sentenceTokens(model *Model, sentence []string) []string {
+	tokenizer := modelTokenizer(model)
+
+	tokens := make([]string, 0, len(sentence))
+	for _, element := range sentence {
+		tokens = append(tokens, tokenizer.Tokenize(element)...)
+	}
+	return tokens
+}
+
+// vocabForm returns the form of a query word in the vocabulary,
+// the word itself if the tokenizer does not turn it into exactly one token
+func vocabForm(model *Model, word string) string {
+	if tokens := modelTokenizer(model).Tokenize(word); len(tokens) == 1 {
+		return tokens[0]
+	}
+	return word
+}
+
+// lookupWord returns the index of a query word, as given (e.g. for loaded GoogleNews vectors)
+// or in the form produced by the tokenizer of the model
+func lookupWord(model *Model, word string) (int, bool) {
+	if idx, ok := model.Word2Index[word]; ok {
+		return idx, true
+	}
+	idx, ok := model.Word2Index[vocabForm(model, word)]
+	return idx, ok
+}
@@ -0,0 +1,63 @@
+package word2vec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnicodeTokenizer(t *testing.T) {
+	fmt.Println("TestUnicodeTokenizer")
+	cases := []struct {
+		tokenizer *UnicodeTokenizer
+		text      string
+		expected  []string
+	}{
+		{DefaultTokenizer(), "El Año pasado, en el café.", []string{"el", "año", "pasado", "en", "el", "café"}},
+		{DefaultTokenizer(), "I don't know (really)!", []string{"i", "don't", "know", "really"}},
+		{DefaultTokenizer(), "a well-known 'quote' - end", []string{"a", "well-known", "quote", "end"}},
+		{DefaultTokenizer(), "pi is 3.14, not 1,000.", []string{"pi", "is", "3.14", "not", "1,000"}},
+		{DefaultTokenizer(), "hyphen\u00adation new_york", []string{"hyphenation", "new_york"}},
+		{&UnicodeTokenizer{}, "Café Straße", []string{"Café", "Straße"}},
+		{&UnicodeTokenizer{Lowercase: true, FoldAccents: true}, "Año Café Café Ł", []string{"ano", "cafe", "cafe", "l"}},
+		{&UnicodeTokenizer{SplitHyphens: true}, "well-known", []string{"well", "known"}},
+		{&UnicodeTokenizer{NumberToken: "<num>", URLToken: "<url>"}, "see https://es.dbpedia.org/page 42 times, 3.5%", []string{"see", "<url>", "<num>", "times", "<num>"}},
+		{&UnicodeTokenizer{Lowercase: true, NumberToken: "<num>", URLToken: "<URL>"}, "<num> route_<num> (<URL>) x<num>", []string{"<num>", "route_<num>", "<URL>", "x", "num"}},
+		{&UnicodeTokenizer{Lowercase: true, NumberToken: "NUM"}, "NUM NUMBER 7", []string{"NUM", "number", "NUM"}},
+	}
+
+	for _, tc := range cases {
+		got := tc.tokenizer.Tokenize(tc.text)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Tokenize(%q) = %q instead of %q", tc.text, got, tc.expected)
+		}
+
+		// tokens are tokenized to themselves
+		again := tc.tokenizer.Tokenize(strings.Join(got, " "))
+		if !reflect.DeepEqual(again, got) {
+			t.Errorf("Tokenize(%q) = %q instead of the same tokens", strings.Join(got, " "), again)
+		}
+	}
+}
+
+func TestModelTokenizer(t *testing.T) {
+	fmt.Println("TestModelTokenizer")
+	model := newModel(1, 1, 10)
+	model.Tokenizer = &UnicodeTokenizer{Lowercase: true, FoldAccents: true}
+
+	model = BuildVocab([][]string{{"El", "año,", "el", "ano", "café!"}}, model)
+	model = ScaleVocab(model)
+
+	if len(model.Vocab) != 3 || model.RawVocab["ano"].Count != 2 || model.RawVocab["cafe"] == nil {
+		t.Errorf("Got vocab %v instead of el, ano and cafe", model.Word2Index)
+	}
+
+	// queries are tokenized like the corpus
+	if idx, ok := lookupWord(model, "AÑO"); !ok || model.Vocab[idx].Literal != "ano" {
+		t.Errorf("Query AÑO did not find ano")
+	}
+	if _, ok := lookupWord(model, "el año"); ok {
+		t.Errorf("Query of two words found a single word")
+	}
+}
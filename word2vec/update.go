@@ -0,0 +1,152 @@
+package word2vec
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Online vocabulary update like gensim's build_vocab(sentences, update=True):
+// counts of the new sentences are added to the vocabulary, words reaching MinCount are appended
+// with fresh vectors and the weights grow by their rows, the trained rows are kept.
+
+// UpdateVocab adds the words of sentences to a trained model, see UpdateVocabCorpus
+func UpdateVocab(sentences [][]string, model *Model) *Model {
+	model, _ = UpdateVocabCorpus(SliceCorpus(sentences), model)
+	return model
+}
+
+// UpdateVocabCorpus adds the word counts of corpus to the vocabulary of a trained model and appends the
+// words reaching MinCount. Only the rows of the new words are initialized, the Huffman tree and the
+// negative sampling table are rebuilt from the new counts. Epoch and WordCount are reset, so
+// TrainCorpus continues with model.Epochs epochs over the new corpus.
+func UpdateVocabCorpus(corpus Corpus, model *Model) (*Model, error) {
+	oldSize := len(model.Vocab)
+	corpusCounts := make(map[string]int)
+
+	err := corpus.Iterate(func(sentence []string) error {
+		for _, cleanWord := range sentenceTokens(model, sentence) {
+			if phraseObj, ok := model.RawVocab[cleanWord]; ok {
+				phraseObj.Count++
+			} else {
+				model.RawVocab[cleanWord] = &Phrase{
+					Literal: cleanWord,
+					Count:   1,
+				}
+			}
+			corpusCounts[cleanWord]++
+		}
+		return nil
+	})
+	if err != nil {
+		return model, err
+	}
+
+	newWords := make(Vocab, 0)
+	for word, phraseObj := range model.RawVocab {
+		if _, ok := model.Word2Index[word]; !ok && phraseObj.Count >= model.MinCount {
+			newWords = append(newWords, phraseObj)
+		}
+	}
+
+	// map order is random, append the new words by count and literal
+	sort.Slice(newWords, func(i, j int) bool {
+		if newWords[i].Count != newWords[j].Count {
+			return newWords[i].Count > newWords[j].Count
+		}
+		return newWords[i].Literal < newWords[j].Literal
+	})
+
+	for _, phraseObj := range newWords {
+		if model.MaxVocabSize > 0 && len(model.Vocab) >= model.MaxVocabSize {
+			break
+		}
+
+		phraseObj.Id = len(model.Vocab)
+		model.Vocab = append(model.Vocab, phraseObj)
+		model.Word2Index[phraseObj.Literal] = phraseObj.Id
+	}
+
+	// alpha decays over the words of the new corpus, the subsampling uses all counts
+	vocabCount := 0
+	for _, phraseObj := range model.Vocab {
+		vocabCount += phraseObj.Count
+	}
+	thresholdCount := model.Sample * float64(vocabCount)
+	if model.Sample <= 0 {
+		// gensim: no downsampling
+		thresholdCount = float64(vocabCount)
+	}
+	for _, phraseObj := range model.Vocab {
+		v := float64(phraseObj.Count)
+		wordProbability := math.Min((math.Sqrt(v/thresholdCount)+1)*(thresholdCount/v), 1.0)
+		phraseObj.SampleInt = int(wordProbability * math.Pow(2, 32))
+	}
+
+	// like ScaleVocab only count the words training will see, or alpha stops short of MinAlpha
+	model.TotalCorpusCount = 0
+	for word, count := range corpusCounts {
+		if _, ok := model.Word2Index[word]; ok {
+			model.TotalCorpusCount += count
+		}
+	}
+
+	if model.HS {
+		model = createBinaryTree(model)
+	}
+	if model.Negative > 0 {
+		model = makeCumTable(model)
+	}
+
+	model = growWeights(model, oldSize)
+
+	model.Epoch = 0
+	model.WordCount = 0
+	return model, nil
+}
+
+// growWeights resizes the weights to the vocabulary, rows from oldSize on are initialized like resetWeights
+func growWeights(model *Model, oldSize int) *Model {
+	size := len(model.Vocab)
+
+	model.Syn0 = growDense(model.Syn0, size, model.VecDim)
+	for index := oldSize; index < size; index++ {
+		model.Syn0.SetRow(index, seededVector(model, model.Vocab[index].Literal))
+	}
+	for index, phraseObj := range model.Vocab {
+		phraseObj.Vector = model.Syn0.RowView(index)
+	}
+
+	if model.HS {
+		model.Syn1 = growDense(model.Syn1, size, model.VecDim)
+	}
+	if model.Negative > 0 {
+		model.Syn1Neg = growDense(model.Syn1Neg, size, model.VecDim)
+	}
+
+	if model.Buckets > 0 {
+		model.Syn0Vocab = growDense(model.Syn0Vocab, size, model.VecDim)
+		for index := oldSize; index < size; index++ {
+			model.Syn0Vocab.SetRow(index, model.Syn0.RawRowView(index))
+			model.Vocab[index].Ngrams = ngramBuckets(model.Vocab[index].Literal, model)
+		}
+	}
+
+	model.Syn0Norm = nil
+	return model
+}
+
+// growDense copies the rows of m into a zero matrix with rows rows, m may be nil
+func growDense(m *mat64.Dense, rows int, cols int) *mat64.Dense {
+	grown := mat64.NewDense(rows, cols, nil)
+	if m == nil {
+		return grown
+	}
+
+	r, _ := m.Dims()
+	for i := 0; i < r && i < rows; i++ {
+		grown.SetRow(i, m.RawRowView(i))
+	}
+	return grown
+}
@@ -0,0 +1,63 @@
+package word2vec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestUpdateVocab(t *testing.T) {
+	fmt.Println("TestUpdateVocab")
+	model := Train(tinySentences, testModel(t, testConfig(2), tinySentences))
+
+	trained := mat64.DenseCopyOf(model.Syn0)
+	dogIndex := model.Word2Index["dog"]
+
+	newSentences := [][]string{{"a", "bird", "and", "a", "dog"}, {"You", "want", "a", "bird"}}
+	model = UpdateVocab(newSentences, model)
+
+	if len(model.Vocab) != 8 {
+		t.Errorf("Got %d instead of 8 words", len(model.Vocab))
+	}
+	if model.Vocab[6].Literal != "bird" || model.Word2Index["bird"] != 6 {
+		t.Errorf("Got %s instead of bird as first new word", model.Vocab[6].Literal)
+	}
+	if model.Word2Index["dog"] != dogIndex || model.Vocab[dogIndex].Count != 2 {
+		t.Errorf("Existing word changed index or count")
+	}
+	if model.TotalCorpusCount != 9 || model.Epoch != 0 {
+		t.Errorf("Got corpus count %d and epoch %d instead of 9 and 0", model.TotalCorpusCount, model.Epoch)
+	}
+
+	rows, _ := model.Syn0.Dims()
+	if r, _ := model.Syn1.Dims(); rows != 8 || r != 8 {
+		t.Errorf("Weights have %d and %d instead of 8 rows", rows, r)
+	}
+	for index := 0; index < 6; index++ {
+		if !mat64.Equal(model.Syn0.RowView(index), trained.RowView(index)) {
+			t.Errorf("Vector of %s was reset", model.Vocab[index].Literal)
+		}
+	}
+	if mat64.Norm(model.Vocab[6].Vector, 2) == 0 {
+		t.Errorf("Vector of bird was not initialized")
+	}
+
+	model = Train(newSentences, model)
+	if mat64.Equal(model.Syn0.RowView(dogIndex), trained.RowView(dogIndex)) {
+		t.Errorf("Continued training did not update dog")
+	}
+	if model.Syn0Norm == nil {
+		t.Errorf("Normalized vectors missing after training")
+	}
+
+	// words below MinCount are not trained, so they do not count for the learning rate decay
+	model.MinCount = 2
+	model = UpdateVocab([][]string{{"a", "rare", "cat"}, {"a", "cat"}}, model)
+	if _, ok := model.Word2Index["rare"]; ok {
+		t.Errorf("rare was added below MinCount")
+	}
+	if model.TotalCorpusCount != 4 {
+		t.Errorf("Got corpus count %d instead of 4", model.TotalCorpusCount)
+	}
+}
@@ -0,0 +1,340 @@
+package word2vec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"unsafe"
+)
+
+// A vector store is a read-only binary copy of the vectors of a model for serving. The file is
+// memory-mapped where the platform supports it, so opening is instant and processes serving the
+// same file share its pages. Layout, all little-endian:
+//
+//	header:  "W2VSTORE", version uint32, dim uint32, size uint32, padding uint32, vocab offset uint64
+//	vectors: size * dim float32, row-major
+//	norms:   size float32, the L2 norm of every vector
+//	vocab:   size times a uint32 byte length followed by the word
+//	tokenizer: a uint32 byte length followed by the json options of the UnicodeTokenizer of the
+//	         model, null for DefaultTokenizer; queries are looked up in the form it produces
+
+const (
+	vectorStoreMagic      = "W2VSTORE"
+	vectorStoreVersion    = 1
+	vectorStoreHeaderSize = 32
+)
+
+// VectorStore queries a vector store file, all methods are safe for concurrent use until Close
+type VectorStore struct {
+	Dim       int
+	Words     []string
+	data      []byte // the mapped file
+	vectors   []float32
+	norms     []float32
+	index     map[string]int
+	tokenizer Tokenizer
+}
+
+// WriteVectorStore writes the vectors of the model to path as a vector store.
+// The file is written next to path and renamed, processes that mapped the old file keep their copy.
+func WriteVectorStore(model *Model, path string) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	err = writeVectorStore(bufio.NewWriter(f), model)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func writeVectorStore(w *bufio.Writer, model *Model) error {
+	size, dim := len(model.Vocab), model.VecDim
+	vocabOffset := vectorStoreHeaderSize + 4*size*dim + 4*size
+
+	header := make([]byte, vectorStoreHeaderSize)
+	copy(header, vectorStoreMagic)
+	binary.LittleEndian.PutUint32(header[8:], vectorStoreVersion)
+	binary.LittleEndian.PutUint32(header[12:], uint32(dim))
+	binary.LittleEndian.PutUint32(header[16:], uint32(size))
+	binary.LittleEndian.PutUint64(header[24:], uint64(vocabOffset))
+	w.Write(header)
+
+	buf := make([]byte, 4)
+	norms := make([]float32, 0, size)
+	for _, phraseObj := range model.Vocab {
+		norm := 0.0
+		for _, elem := range phraseObj.Vector.RawVector().Data {
+			f := float32(elem)
+			norm += float64(f) * float64(f)
+
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(f))
+			w.Write(buf)
+		}
+		norms = append(norms, float32(math.Sqrt(norm)))
+	}
+
+	for _, norm := range norms {
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(norm))
+		w.Write(buf)
+	}
+
+	for _, phraseObj := range model.Vocab {
+		binary.LittleEndian.PutUint32(buf, uint32(len(phraseObj.Literal)))
+		w.Write(buf)
+		w.WriteString(phraseObj.Literal)
+	}
+
+	options, err := encodeTokenizer(model.Tokenizer)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(buf, uint32(len(options)))
+	w.Write(buf)
+	w.Write(options)
+
+	return w.Flush()
+}
+
+// ConvertTextModel converts a model file in the given format (e.g. 100k_model.txt in the Legacy format)
+// to a vector store
+func ConvertTextModel(modelPath string, format Format, storePath string) error {
+	model, err := LoadFormat(modelPath, format)
+	if err != nil {
+		return err
+	}
+	return WriteVectorStore(model, storePath)
+}
+
+// OpenVectorStore maps the vector store at path, call Close to release it
+func OpenVectorStore(path string) (*VectorStore, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := newVectorStore(data)
+	if err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	return store, nil
+}
+
+func newVectorStore(data []byte) (*VectorStore, error) {
+	if len(data) < vectorStoreHeaderSize || string(data[:8]) != vectorStoreMagic {
+		return nil, &HeaderError{Header: string(data[:minInt(len(data), 8)])}
+	}
+	if version := binary.LittleEndian.Uint32(data[8:]); version != vectorStoreVersion {
+		return nil, fmt.Errorf("word2vec: unsupported vector store version %d", version)
+	}
+
+	dim := uint64(binary.LittleEndian.Uint32(data[12:]))
+	size := uint64(binary.LittleEndian.Uint32(data[16:]))
+	vocabOffset := binary.LittleEndian.Uint64(data[24:])
+
+	// every word takes 4*dim bytes of vector and 4 of norm, checked before the offsets are
+	// computed so that they can not overflow and before anything is allocated for size words
+	if dim == 0 || size > uint64(len(data)-vectorStoreHeaderSize)/(4*(dim+1)) {
+		return nil, fmt.Errorf("word2vec: vector store of %d bytes can not hold %d vectors of dimension %d", len(data), size, dim)
+	}
+	normsOffset := vectorStoreHeaderSize + 4*size*dim
+	if vocabOffset != normsOffset+4*size {
+		return nil, fmt.Errorf("word2vec: vector store vocabulary offset %d, expected %d", vocabOffset, normsOffset+4*size)
+	}
+
+	store := &VectorStore{
+		Dim:     int(dim),
+		Words:   make([]string, 0, size),
+		data:    data,
+		vectors: float32View(data[vectorStoreHeaderSize:normsOffset]),
+		norms:   float32View(data[normsOffset:vocabOffset]),
+		index:   make(map[string]int, size),
+	}
+
+	offset := int(vocabOffset)
+	for i := 0; i < int(size); i++ {
+		if len(data)-offset < 4 {
+			return nil, fmt.Errorf("word2vec: vector store vocabulary is truncated after %d words", i)
+		}
+		length := uint64(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+		if length > uint64(len(data)-offset) {
+			return nil, fmt.Errorf("word2vec: vector store vocabulary is truncated after %d words", i)
+		}
+
+		word := string(data[offset : offset+int(length)])
+		offset += int(length)
+
+		store.Words = append(store.Words, word)
+		store.index[word] = i
+	}
+
+	if len(data)-offset < 4 {
+		return nil, fmt.Errorf("word2vec: vector store tokenizer options are truncated")
+	}
+	length := uint64(binary.LittleEndian.Uint32(data[offset:]))
+	offset += 4
+	if length > uint64(len(data)-offset) {
+		return nil, fmt.Errorf("word2vec: vector store tokenizer options are truncated")
+	}
+	tokenizer, err := decodeTokenizer(data[offset : offset+int(length)])
+	if err != nil {
+		return nil, err
+	}
+	store.tokenizer = tokenizer
+
+	return store, nil
+}
+
+// littleEndian is true if float32 values in memory have the byte order of the file
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// float32View returns the little-endian floats in b without copying if the platform allows it
+func float32View(b []byte) []float32 {
+	n := len(b) / 4
+	if n == 0 {
+		return nil
+	}
+
+	if littleEndian && uintptr(unsafe.Pointer(&b[0]))%4 == 0 {
+		return unsafe.Slice((*float32)(unsafe.Pointer(&b[0])), n)
+	}
+
+	floats := make([]float32, n)
+	for i := range floats {
+		floats[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return floats
+}
+
+// Close unmaps the file, the store must not be used afterwards
+func (s *VectorStore) Close() error {
+	data := s.data
+	s.data, s.vectors, s.norms = nil, nil, nil
+	return unmapFile(data)
+}
+
+// Len returns the number of words in the store
+func (s *VectorStore) Len() int {
+	return len(s.Words)
+}
+
+func (s *VectorStore) lookup(word string) (int, bool) {
+	return lookupIndex(s.index, s.tokenizer, word)
+}
+
+// lookupIndex finds word in an index without a model, as given or in the form of tokenizer,
+// nil uses DefaultTokenizer
+func lookupIndex(index map[string]int, tokenizer Tokenizer, word string) (int, bool) {
+	if idx, ok := index[word]; ok {
+		return idx, true
+	}
+	if tokenizer == nil {
+		tokenizer = DefaultTokenizer()
+	}
+	if tokens := tokenizer.Tokenize(word); len(tokens) == 1 {
+		idx, ok := index[tokens[0]]
+		return idx, ok
+	}
+	return 0, false
+}
+
+func (s *VectorStore) row(idx int) []float32 {
+	return s.vectors[idx*s.Dim : (idx+1)*s.Dim]
+}
+
+// Vector returns a copy of the vector of word
+func (s *VectorStore) Vector(word string) ([]float32, error) {
+	idx, ok := s.lookup(word)
+	if !ok {
+		return nil, &UnknownWordError{Word: word}
+	}
+
+	vec := make([]float32, s.Dim)
+	copy(vec, s.row(idx))
+	return vec, nil
+}
+
+// Similarity returns the cosine similarity of two words
+func (s *VectorStore) Similarity(word1 string, word2 string) (float64, error) {
+	idx1, ok := s.lookup(word1)
+	if !ok {
+		return 0, &UnknownWordError{Word: word1}
+	}
+	idx2, ok := s.lookup(word2)
+	if !ok {
+		return 0, &UnknownWordError{Word: word2}
+	}
+
+	length := float64(s.norms[idx1]) * float64(s.norms[idx2])
+	if length == 0 {
+		return 0, nil
+	}
+	return dot32(s.row(idx1), s.row(idx2)) / length, nil
+}
+
+// MostSimilar returns the top words by cosine similarity to word, without word itself
+func (s *VectorStore) MostSimilar(word string, top int) ([]SimPair, error) {
+	idx, ok := s.lookup(word)
+	if !ok {
+		return nil, &UnknownWordError{Word: word}
+	}
+	return s.mostSimilar(s.row(idx), top, map[int]bool{idx: true}), nil
+}
+
+// MostSimilarByVector returns the top words by cosine similarity to vec
+func (s *VectorStore) MostSimilarByVector(vec []float32, top int) ([]SimPair, error) {
+	if len(vec) != s.Dim {
+		return nil, &DimensionError{Word: "query", Expected: s.Dim, Got: len(vec)}
+	}
+	return s.mostSimilar(vec, top, nil), nil
+}
+
+func (s *VectorStore) mostSimilar(vec []float32, top int, exclude map[int]bool) []SimPair {
+	norm := math.Sqrt(dot32(vec, vec))
+
+	sims := make([]float64, len(s.Words))
+	for i := range sims {
+		if length := norm * float64(s.norms[i]); length > 0 {
+			sims[i] = dot32(vec, s.row(i)) / length
+		} else {
+			sims[i] = math.Inf(-1)
+		}
+	}
+
+	return topSimilarLabels(sims, top, exclude, func(idx int) string { return s.Words[idx] })
+}
+
+func dot32(a, b []float32) float64 {
+	sum := float32(0)
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return float64(sum)
+}
@@ -0,0 +1,188 @@
+package word2vec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestVectorStore(t *testing.T) {
+	fmt.Println("TestVectorStore")
+	model := analogyModel()
+	model.Tokenizer = &UnicodeTokenizer{Lowercase: true, FoldAccents: true}
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "model.store")
+	if err := WriteVectorStore(model, path); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenVectorStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if store.Len() != len(model.Vocab) || store.Dim != model.VecDim {
+		t.Fatalf("Got %d words of dimension %d instead of %d of %d", store.Len(), store.Dim, len(model.Vocab), model.VecDim)
+	}
+
+	// queries are tokenized by the tokenizer of the model
+	if _, err := store.Vector("KÍNG"); err != nil {
+		t.Errorf("Query KÍNG did not find king: %v", err)
+	}
+
+	for _, phraseObj := range model.Vocab {
+		vec, err := store.Vector(phraseObj.Literal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range phraseObj.Vector.RawVector().Data {
+			if float64(vec[i]) != float64(float32(v)) {
+				t.Errorf("Got %f instead of %f for %s", vec[i], v, phraseObj.Literal)
+			}
+		}
+
+		expected, err := MostSimilarWords([]string{phraseObj.Literal}, nil, 3, model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sims, err := store.MostSimilar(phraseObj.Literal, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			if sims[i].Key != expected[i].Key || math.Abs(sims[i].Sim-expected[i].Sim) > 1e-5 {
+				t.Errorf("Got %v instead of %v for %s", sims, expected, phraseObj.Literal)
+				break
+			}
+		}
+	}
+
+	sim, err := store.Similarity("King", "queen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := WordSimilarity("king", "queen", model)
+	if math.Abs(sim-expected) > 1e-5 {
+		t.Errorf("Got similarity %f instead of %f", sim, expected)
+	}
+
+	if _, err := store.Vector("banana"); err == nil {
+		t.Error("Expected an error for a word out of vocabulary")
+	}
+	if _, err := store.MostSimilarByVector([]float32{1, 2}, 3); err == nil {
+		t.Error("Expected an error for a query of the wrong dimension")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if sims, err := store.MostSimilarByVector([]float32{1, 1, 0, 0.1}, 1); err != nil || sims[0].Key != "king" {
+					t.Errorf("Got %v, %v instead of king", sims, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConvertTextModel(t *testing.T) {
+	fmt.Println("TestConvertTextModel")
+	model := analogyModel()
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	textPath := filepath.Join(dir, "model.txt")
+	storePath := filepath.Join(dir, "model.store")
+	if err := SaveFormat(model, textPath, Text); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertTextModel(textPath, Text, storePath); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenVectorStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	sims, err := store.MostSimilar("apple", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sims) != 1 || sims[0].Key != "pear" {
+		t.Errorf("Got %v instead of pear", sims)
+	}
+}
+
+func TestOpenVectorStoreCorrupt(t *testing.T) {
+	fmt.Println("TestOpenVectorStoreCorrupt")
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "model.store")
+	if err := ioutil.WriteFile(path, []byte("3 4\nnot a store"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenVectorStore(path); err == nil {
+		t.Error("Expected an error for a file without the vector store header")
+	}
+
+	if err := WriteVectorStore(analogyModel(), path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data[:len(data)-3], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenVectorStore(path); err == nil {
+		t.Error("Expected an error for a truncated vector store")
+	}
+
+	// a header announcing more vectors than the file holds fails before allocating them
+	for _, header := range []struct {
+		dim, size   uint32
+		vocabOffset uint64
+	}{
+		{0xffffffff, 1 << 30, vectorStoreHeaderSize},
+		{1, 1 << 30, vectorStoreHeaderSize + 8<<30},
+		{0, 1, vectorStoreHeaderSize},
+		{0xffffffff, 0xffffffff, 0xffffffffffffffff},
+	} {
+		corrupt := make([]byte, 64)
+		copy(corrupt, vectorStoreMagic)
+		binary.LittleEndian.PutUint32(corrupt[8:], vectorStoreVersion)
+		binary.LittleEndian.PutUint32(corrupt[12:], header.dim)
+		binary.LittleEndian.PutUint32(corrupt[16:], header.size)
+		binary.LittleEndian.PutUint64(corrupt[24:], header.vocabOffset)
+		if _, err := newVectorStore(corrupt); err == nil {
+			t.Errorf("Expected an error for dimension %d, size %d and vocabulary offset %d", header.dim, header.size, header.vocabOffset)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package word2vec
 
 import (
-	"fmt"
 	// "github.com/a-h/round"
 	// "github.com/soeffing/nlp/matrix"
 	// "github.com/soeffing/nlp/vector"
@@ -10,6 +9,9 @@ import (
 	// "github.com/gonum/lapack/lapack64"
 	// "github.com/gonum/matrix"
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"sort"
 	// "bytes"
 	// "encoding/binary"
@@ -22,7 +24,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
+	"sync/atomic"
+	"unicode"
 	//	"github.com/gonum/matrix"
 )
 
@@ -31,25 +35,57 @@ import (
 
 // python implementation: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/word2vec.py
 
+// Architecture selects the network trained by Train
+type Architecture int
+
+const (
+	// SkipGram predicts the surrounding words from the center word
+	SkipGram Architecture = iota
+	// CBOW predicts the center word from its (summed or averaged) context
+	CBOW
+)
+
 // Model contains the word2vec vocab, vectors, parameters, etc.
 type Model struct {
 	RawVocab         map[string]*Phrase
 	Vocab            []*Phrase
 	Word2Index       map[string]int
 	MinCount         int
+	MaxVocabSize     int // keep only the most frequent words, 0 is unlimited
 	Sample           float64
 	CumTable         []int
 	Syn0             *mat64.Dense
+	Syn1             *mat64.Dense // hierarchical softmax inner nodes, size: vocab_size * vector_dimension
 	Syn1Neg          *mat64.Dense // size: vocab_size * vector_dimension
-	Syn0Norm         *mat64.Dense
+	Syn0Norm         *mat64.Dense // Syn0 rows scaled to unit length, see InitSims
+	Syn0Vocab        *mat64.Dense // subwords: word rows without n-grams, size: vocab_size * vector_dimension
+	Syn0Ngrams       *mat64.Dense // subwords: hashed n-gram rows, size: buckets * vector_dimension
+	MinN             int          // subwords: shortest character n-gram
+	MaxN             int          // subwords: longest character n-gram
+	Buckets          int          // subwords: number of n-gram hash buckets, 0 disables subwords
 	Window           int
-	Negative         int
+	ShrinkWindow     bool    // draw the window of every word from [1, Window]
+	Negative         int     // number of negative samples, 0 disables negative sampling
+	NSExponent       float64 // negative samples are drawn by count ^ NSExponent, 1 is the unigram distribution, 0 uniform
+	HS               bool    // train with hierarchical softmax
 	TotalCorpusCount int
-	Alpha            float64
+	Alpha            float64 // starting learning rate
+	MinAlpha         float64 // learning rate at the end of the last epoch
 	Epochs           int
 	VecDim           int
-	seed             int64
+	Architecture     Architecture
+	CBOWMean         bool      // average (instead of sum) the context vectors in CBOW mode
+	Workers          int       // number of training goroutines, runs are only reproducible with 1
+	Epoch            int       // completed training epochs
+	WordCount        int64     // words processed by Train, drives the learning rate decay
+	CheckpointPath   string    // Train writes a checkpoint here after every epoch
+	Observer         Observer  // receives training progress, may be nil
+	ReportInterval   int64     // words between progress reports, 0 only reports epoch ends
+	TrainingLoss     float64   // loss of the output layers accumulated by the last Train call
+	Seed             int64     // seeds the initial vectors and the training random sources
+	Tokenizer        Tokenizer // splits sentence elements and query words, nil uses DefaultTokenizer
 	stopwords        []string
+	normMu           sync.Mutex // guards Syn0Norm for concurrent queries
 }
 
 // Phrase contains the literal text, vecotr and count of a given phrase
@@ -63,12 +99,11 @@ type Phrase struct {
 	// used for populating NegativeSamplingTable
 	Probability float64
 	Updated     int64
-}
-
-func check(e error) {
-	if e != nil {
-		panic(e)
-	}
+	// Huffman code and inner node indices used by hierarchical softmax
+	Code  []uint8
+	Point []int
+	// Syn0Ngrams rows of the character n-grams
+	Ngrams []int
 }
 
 type Vocab []*Phrase
@@ -81,41 +116,38 @@ func (p Vocab) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
 // func train_sg_pair()
 
-// InitModel initiliazes the model
-func InitModel(epochs int, minCount int, dim int) *Model {
-	stopwords := make([]string, 0)
-
-	f, err := os.Open("stopwords.txt")
-	if err != nil {
-		os.Exit(1)
-	}
+// InitModel initiliazes the model with DefaultConfig, it expects stopwords.txt in the current directory
+func InitModel(epochs int, minCount int, dim int) (*Model, error) {
+	config := DefaultConfig()
+	config.Epochs = epochs
+	config.MinCount = minCount
+	config.VecDim = dim
 
-	s := bufio.NewScanner(f)
-	s.Split(bufio.ScanLines)
+	return InitModelConfig(config)
+}
 
-	for s.Scan() {
-		stopwords = append(stopwords, s.Text())
-	}
+// newModel creates a model with the default parameters and no stopwords
+func newModel(epochs int, minCount int, dim int) *Model {
+	config := DefaultConfig()
+	config.Epochs = epochs
+	config.MinCount = minCount
+	config.VecDim = dim
 
-	// fmt.Printf("stopwords: %v\n", stopwords)
+	return newModelConfig(config)
+}
 
+// newModelConfig creates an empty model with the given parameters and no stopwords
+func newModelConfig(config Config) *Model {
 	model := &Model{
 		RawVocab:         make(map[string]*Phrase),
 		Vocab:            make(Vocab, 0),
-		MinCount:         minCount,
-		Sample:           0.001,
 		CumTable:         make([]int, 0),
 		Word2Index:       make(map[string]int),
-		Window:           7,
-		Negative:         5,
 		TotalCorpusCount: 0,
-		Alpha:            0.025,
-		Epochs:           epochs,
-		VecDim:           dim,
-		seed:             7456393,
-		stopwords:        stopwords,
+		ReportInterval:   10000,
+		stopwords:        make([]string, 0),
 	}
-	return model
+	return applyConfig(model, config)
 }
 
 // Similarity returns the cosine similarity between two words
@@ -172,7 +204,9 @@ func (p SimPairList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func MostSimilar(positive string, top int, model *Model) []SimPair {
 	// fmt.Println(positive)
 	spl := make(SimPairList, len(model.Vocab))
-	fmt.Printf("Positive: %v\n", positive)
+	if idx, ok := lookupWord(model, positive); ok {
+		positive = model.Vocab[idx].Literal
+	}
 	for idx, phraseObj := range model.Vocab {
 		if phraseObj.Literal != positive {
 			//fmt.Printf("phraseObj.Literal: %v\n", phraseObj.Literal)
@@ -240,12 +274,15 @@ func stringInSlice(a string, list []string) bool {
 
 // BuildVocab cycles through sentences and builds vocab
 func BuildVocab(sentences [][]string, model *Model) *Model {
-	for _, sentence := range sentences {
-		for _, word := range sentence {
+	// a SliceCorpus never fails
+	model, _ = BuildVocabCorpus(SliceCorpus(sentences), model)
+	return model
+}
 
-			temp := cleanString(word)
-			cleanWord := strings.Replace(temp, " ", "", -1)
-			cleanWord = strings.ToLower(cleanWord)
+// BuildVocabCorpus makes one pass over the corpus and builds vocab
+func BuildVocabCorpus(corpus Corpus, model *Model) (*Model, error) {
+	err := corpus.Iterate(func(sentence []string) error {
+		for _, cleanWord := range sentenceTokens(model, sentence) {
 
 			//if stringInSlice(cleanWord, model.stopwords) == true {
 			//continue
@@ -265,16 +302,36 @@ func BuildVocab(sentences [][]string, model *Model) *Model {
 			}
 			// //fmt.Println(word)
 		}
-	}
+		return nil
+	})
 
-	return model
+	return model, err
 }
 
 // ScaleVocab applies MinCount
 func ScaleVocab(model *Model) *Model {
 	// retainTotal := 0
 	idx := 0
-	for word, phraseObj := range model.RawVocab {
+
+	// gensim sort_vocab: frequent words first, ties by literal, so ids do not depend on map order
+	words := make([]string, 0, len(model.RawVocab))
+	for word := range model.RawVocab {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		ci, cj := model.RawVocab[words[i]].Count, model.RawVocab[words[j]].Count
+		if ci != cj {
+			return ci > cj
+		}
+		return words[i] < words[j]
+	})
+
+	for _, word := range words {
+		if model.MaxVocabSize > 0 && idx >= model.MaxVocabSize {
+			break
+		}
+
+		phraseObj := model.RawVocab[word]
 		if phraseObj.Count >= model.MinCount {
 			phraseObj.Id = idx
 			model.Vocab = append(model.Vocab, phraseObj)
@@ -291,6 +348,10 @@ func ScaleVocab(model *Model) *Model {
 	// TODO: Double check those calculation with http://mccormickml.com/2017/01/11/word2vec-tutorial-part-2-negative-sampling/
 
 	thresholdCount := model.Sample * float64(model.TotalCorpusCount)
+	if model.Sample <= 0 {
+		// gensim: no downsampling
+		thresholdCount = float64(model.TotalCorpusCount)
+	}
 
 	downsampleTotal := 0.0
 	downsampleUnique := 0.0
@@ -327,8 +388,12 @@ func ScaleVocab(model *Model) *Model {
 // FinalizeVocab creates the weigths and the
 func FinalizeVocab(model *Model) *Model {
 	// sort vocab?
-	// model = makeCumTable(model)
-	model = makeCumTableAlternative(model)
+	if model.HS {
+		model = createBinaryTree(model)
+	}
+	if model.Negative > 0 {
+		model = makeCumTable(model)
+	}
 	// fmt.Printf("model.CumTable: %v\n", model.CumTable)
 	model = resetWeights(model)
 
@@ -336,66 +401,44 @@ func FinalizeVocab(model *Model) *Model {
 }
 
 // http://mccormickml.com/2017/01/11/word2vec-tutorial-part-2-negative-sampling/
-// The way this selection is implemented in the C code is interesting.
-// They have a large array with 100M elements (which they refer to as the unigram table).
-// They fill this table with the index of each word in the vocabulary multiple times,
-// and the number of times a word’s index appears in the table is given by P(wi) * table_size.
-// Then, to actually select a negative sample, you just generate a random integer between 0 and 100M,
-// and use the word at that index in the table. Since the higher probability words occur more times in the table,
-// you’re more likely to pick those.
-
-// Equation: probability of word = word count of word ^0.75 / word count of entire corpus ^0.75
-// Equation 2: probability of word * table_size
-// Table will be an array of 1000 fields (for dev environment) and 100 million fields production environment
+// The C code fills a unigram table of 100M elements with the index of each word P(wi) * table_size times
+// and draws a random element. Like gensim we keep only the cumulative distribution, one entry per word,
+// and find the drawn word by binary search.
 
-func makeCumTableAlternative(model *Model) *Model {
+// Equation: probability of word = word count of word ^ NSExponent / sum of word counts ^ NSExponent
 
-	// fmt.Println("inside makeCumTableAlternative")
-	trainWordsPow := 0.0
-	power := 0.75
+// cumTableDomain is the value of the last CumTable entry, gensim uses 2^31 - 1
+const cumTableDomain = 1<<31 - 1
 
-	for index := range model.Vocab {
-		trainWordsPow += math.Pow(float64(model.Vocab[index].Count), 0.75)
+// makeCumTable creates the cumulative distribution of the word counts raised to NSExponent,
+// CumTable[i] - CumTable[i-1] is proportional to the probability of drawing word i
+func makeCumTable(model *Model) *Model {
+	model.CumTable = make([]int, len(model.Vocab))
+	if len(model.Vocab) == 0 {
+		return model
 	}
 
-	i := 0
-	fmt.Printf("size model.Vocab %v\n", len(model.Vocab))
-	d1 := math.Pow(float64(model.Vocab[i].Count), power) / trainWordsPow
-
-	fmt.Printf("d1: %f\n", d1)
-
-	tableSize := 100000000
-
-	// TODO: set proper table size
-	for a := 1; a <= tableSize; a++ {
-		// model.CumTable = append(model.CumTable, i)
-		// [Chris] - The table may contain multiple elements which hold value 'i'.
-		//
-		model.CumTable = append(model.CumTable, i)
-		// table[a] = i;
-		// [Chris] - If the fraction of the table we have filled is greater than the
-		//           fraction of this words weight / all word weights, then move to the next word.
-		// fmt.Printf("float64(a/tableSize): %f\n", float64(a)/float64(tableSize))
-		if float64(a)/float64(tableSize) > d1 {
-			// fmt.Printf("increase i at index: %d\n", a)
-			if i == len(model.Vocab)-1 {
-				// fmt.Println("lower i")
-				i = len(model.Vocab) - 1
-			} else {
-				i++
-			}
-
-			// fmt.Printf("i: %d\n", i)
-			// fmt.Printf("vocab size: %d\n", len(model.Vocab))
-
-			d1 += math.Pow(float64(model.Vocab[i].Count), power) / trainWordsPow
-		}
+	trainWordsPow := 0.0
+	for _, phraseObj := range model.Vocab {
+		trainWordsPow += math.Pow(float64(phraseObj.Count), model.NSExponent)
+	}
 
+	cumulative := 0.0
+	for index, phraseObj := range model.Vocab {
+		cumulative += math.Pow(float64(phraseObj.Count), model.NSExponent)
+		model.CumTable[index] = int(math.Floor(cumulative/trainWordsPow*cumTableDomain + 0.5))
 	}
+	model.CumTable[len(model.Vocab)-1] = cumTableDomain
 
 	return model
 }
 
+// sampleNegative draws a word index from the distribution in CumTable
+func sampleNegative(model *Model, r *rand.Rand) int {
+	x := r.Intn(model.CumTable[len(model.CumTable)-1])
+	return sort.SearchInts(model.CumTable, x+1)
+}
+
 func resetWeights(model *Model) *Model {
 	// Reset all projection weights to an initial (untrained) state, but keep the existing vocabulary
 
@@ -439,7 +482,19 @@ func resetWeights(model *Model) *Model {
 
 	}
 
-	model.Syn1Neg = mat64.NewDense(len(model.Vocab), model.VecDim, nil)
+	if model.HS {
+		model.Syn1 = mat64.NewDense(len(model.Vocab), model.VecDim, nil)
+	}
+
+	if model.Buckets > 0 {
+		model = resetNgramWeights(model)
+	}
+
+	model.Syn0Norm = nil
+
+	if model.Negative > 0 {
+		model.Syn1Neg = mat64.NewDense(len(model.Vocab), model.VecDim, nil)
+	}
 
 	// layer1_size = vector dimension
 	// zeros(columns, rows)
@@ -482,53 +537,16 @@ func resetWeights(model *Model) *Model {
 // }
 
 func seededVector(model *Model, seedString string) []float64 {
-	// vector.Vector = vector.NewVector(300)
-	// vector = vector.NewVector(300)
-
-	// oneRand := hash(seedString)
-	// // fmt.Println(oneRand)
-	vectorElements := make([]float64, 0)
-	//vectorSlice := make([]float64, 0)
-	seed := model.seed + rand.Int63()
-	// fmt.Printf("seed: %v\n", seed)
-	rand.Seed(seed)
+	// Create one 'random' vector (but deterministic by seed_string)
+	// gensim: once = random.RandomState(self.hashfxn(seed_string) & 0xffffffff)
+	// return (once.rand(self.vector_size) - 0.5) / self.vector_size
+	once := rand.New(rand.NewSource(int64(hash(seedString + strconv.FormatInt(model.Seed, 10)))))
 
+	vectorElements := make([]float64, 0, model.VecDim)
 	for i := 1; i <= model.VecDim; i++ {
-		//vectorElements = append(vectorElements, (rand.Float64()-0.5)/float64(model.VecDim))
-		vectorElements = append(vectorElements, rand.Float64()-0.5)
+		vectorElements = append(vectorElements, (once.Float64()-0.5)/float64(model.VecDim))
 	}
-
-	// fmt.Println("vectorElements")
-	// fmt.Println(vectorElements)
-
-	// actualVector := vector.NewVector(300, vectorElements...)
-	// actualVector.Zero()
-	// actualVector := mat64.NewVector(300, vectorElements)
-	//actualVector := new(vector.Vector)
-
-	// actualVector.InitV(vec)
-
-	// // fmt.Println("actualVector after NewVector")
-	// // fmt.Println(actualVector)
-
-	// // fmt.Println("vectorElements")
-	// // fmt.Println(vectorElements)
-
-	//newVec = actualVector.Init(vectorElements...)
-
-	// actualVector.Set(1, 1.5)
-
-	// // fmt.Println("actualVector")
-	// // fmt.Println(actualVector)
-	// // fmt.Println(newVec)
-	// //fmt.Println(hash(seedString))
-
-	// Create one 'random' vector (but deterministic by seed_string)
-	// Note: built-in hash() may vary by Python version or even (in Py3.x) per launch
-	// once = random.RandomState(self.hashfxn(seed_string) & 0xffffffff)
-	// return (once.rand(self.vector_size) - 0.5) / self.vector_size
 	return vectorElements
-
 }
 
 // From: http://stackoverflow.com/questions/13582519/how-to-generate-hash-number-of-a-string-in-go
@@ -538,52 +556,67 @@ func hash(s string) uint32 {
 	return h.Sum32()
 }
 
-func Save(model *Model, path string) *Model {
+// Save writes the vectors of the model in the Legacy format:
+// a "dim size" header followed by the word on one line and its vector on the next
+func Save(model *Model, path string) error {
 	f, err := os.Create(path)
-	check(err)
+	if err != nil {
+		return err
+	}
 
 	defer f.Close()
 
-	// modelData := make([]int, 0)
-
-	// fmt.Println("len(model.Vocab)")
-	// fmt.Println(len(model.Vocab))
+	newWriter := bufio.NewWriter(f)
 
 	stringDim := strconv.Itoa(model.VecDim)
 	stringSize := strconv.Itoa(len(model.Vocab))
 
-	f.WriteString(stringDim + " " + stringSize)
-
-	f.WriteString("\n")
+	// the config is a third header field, json without spaces (tokenizer options may contain them)
+	config, err := json.Marshal(headerConfig{ModelConfig(model), savedTokenizer(model.Tokenizer)})
+	if err != nil {
+		return err
+	}
+	config = escapeSpaces(config)
 
-	// newReader := bufio.NewReader(f)
-	newWriter := bufio.NewWriter(f)
+	newWriter.WriteString(stringDim + " " + stringSize + " " + string(config))
+	newWriter.WriteString("\n")
 
 	for _, voc := range model.Vocab {
-		vec := model.Syn0.RowView(voc.Id)
 		newWriter.WriteString(voc.Literal + "\n")
 
-		for _, elem := range vec.RawVector().Data {
+		for _, elem := range voc.Vector.RawVector().Data {
 			newWriter.WriteString(strconv.FormatFloat(elem, 'g', 15, 64) + " ")
-			// err := binary.Write(buf, binary.BigEndian, elem)
-			// if err != nil {
-			// // fmt.Println("binary.Write failed:", err)
-			// }
 		}
 		newWriter.WriteString("\n")
-		// f.Write(buf.Bytes())
-
 	}
 
-	newWriter.Flush()
+	return newWriter.Flush()
+}
+
+// escapeSpaces writes the white space in the strings of compact json as \u escapes,
+// so the json is a single header field
+func escapeSpaces(b []byte) []byte {
+	var escaped bytes.Buffer
+	for _, r := range string(b) {
+		if unicode.IsSpace(r) {
+			fmt.Fprintf(&escaped, "\\u%04x", r)
+			continue
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.Bytes()
+}
 
-	// write debug data: update counts of words
-	f, err = os.Create("debug/sorted-updates.txt")
-	check(err)
+// SaveUpdates writes debug data: the words sorted by how often they were updated during training
+func SaveUpdates(model *Model, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
 
 	defer f.Close()
 
-	newWriter = bufio.NewWriter(f)
+	newWriter := bufio.NewWriter(f)
 
 	vocab := make(Vocab, len(model.Vocab))
 	for idx, phraseObj := range model.Vocab {
@@ -598,389 +631,367 @@ func Save(model *Model, path string) *Model {
 	sort.Sort(sort.Reverse(vocab))
 
 	for _, voc := range vocab {
-		// vec := model.Syn0Norm.RowView(voc.Id)
 		newWriter.WriteString(voc.Literal + " " + strconv.FormatInt(voc.Updated, 10) + " " + strconv.FormatInt(int64(voc.Count), 10))
 		newWriter.WriteString("\n")
-		// f.Write(buf.Bytes())
 	}
 
-	newWriter.Flush()
-
-	//modelData = append(modelData, 300)
-	//modelData = append(modelData, len(model.Vocab))
-	//
-	// //fmt.Println("modelData")
-	// //fmt.Println(modelData)
-
-	// buf := new(bytes.Buffer)
-
-	//for _, elem := range modelData {
-	// //fmt.Println("elem")
-	// //fmt.Println(elem)
-	//
-	//r := bytes.NewReader([]byte{elem})
-	//f.Write()
-	//
-	//
-	//// binary.Write(buf, binary.BigEndian, elem)
-	//
-	//}
-
-	// for _, voc := range model.Vocab {
-	// vec := model.Syn0Norm.RowView(voc.Id)
-	//
-	// for _, elem := range vec.RawVector().Data {
-	// f.WriteString(voc.Literal)
-	// err := binary.Write(buf, binary.BigEndian, elem)
-	// if err != nil {
-	// // fmt.Println("binary.Write failed:", err)
-	// }
-	// }
-	// f.Write(buf.Bytes())
-	// f.WriteString("/n")
-	//
-	// }
-	return model
+	return newWriter.Flush()
 }
 
-func Load(modelPath string) *Model {
+// Load reads a model saved with Save
+func Load(modelPath string) (*Model, error) {
+	f, err := openFile(modelPath)
+	if err != nil {
+		return nil, err
+	}
 
-	model := InitModel(50, 5, 0)
+	defer f.Close()
 
-	f, err := os.Open(modelPath)
+	info, err := f.Stat()
 	if err != nil {
-		// fmt.Printf("error opening model data file: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	// br := bufio.NewReader(f)
-
 	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
 	s.Split(bufio.ScanLines)
 
-	size_dim := make([]string, 0)
-	words := make([]string, 0)
-	wordVectorSlice := make([]*Phrase, 0)
-	counter := 0
-	for s.Scan() {
-		if counter == 0 {
-			size_dim = strings.Fields(s.Text())
-			fmt.Printf("Size dim: %v\n", size_dim[0])
-			intDim, _ := strconv.Atoi(size_dim[0])
-			model.VecDim = intDim
-
-		} else if counter%2 == 0 {
-			// // fmt.Println("Even number %i", counter)
-			strVector := strings.Fields(s.Text())
-			floatVector := make([]float64, 0)
-			for _, strFloat := range strVector {
-				if n, err := strconv.ParseFloat(strFloat, 64); err == nil {
-					floatVector = append(floatVector, n)
-				}
-			}
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, &HeaderError{Header: ""}
+	}
 
-			vector := mat64.NewVector(model.VecDim, floatVector)
-			// // fmt.Println("word before setting map: %v", words[len(words)-1])
+	sizeDim := strings.Fields(s.Text())
+	if len(sizeDim) != 2 && len(sizeDim) != 3 {
+		return nil, &HeaderError{Header: s.Text()}
+	}
+	dim, errDim := strconv.Atoi(sizeDim[0])
+	size, errSize := strconv.Atoi(sizeDim[1])
+	// a value takes at least 2 bytes like "0 "
+	if errDim != nil || errSize != nil || !plausibleHeader(size, dim, 2, info.Size()) {
+		return nil, &HeaderError{Header: s.Text()}
+	}
 
-			phrase := &Phrase{
-				Literal: words[len(words)-1],
-				Count:   0,
-				Vector:  vector,
-			}
+	// files written before the config was stored have no third field
+	var config *headerConfig
+	if len(sizeDim) == 3 {
+		config = new(headerConfig)
+		if err := json.Unmarshal([]byte(sizeDim[2]), config); err != nil || config.VecDim != dim {
+			return nil, &HeaderError{Header: s.Text()}
+		}
+	}
 
-			wordVectorSlice = append(wordVectorSlice, phrase)
+	words := make([]string, 0, size)
+	data := make([]float64, 0, size*dim)
 
-		} else {
-			// // fmt.Println("Uneven number %i", counter)
-			word := s.Text()
-			words = append(words, word)
-			// // fmt.Println(word)
-			// wordVectorMap[word] :=
+	for s.Scan() {
+		word := s.Text()
+		if !s.Scan() {
+			return nil, &DimensionError{Word: word, Expected: dim, Got: 0}
 		}
 
-		// // fmt.Println(s.Text())
-		// // fmt.Println("----new line ----")
-		counter++
+		strVector := strings.Fields(s.Text())
+		if len(strVector) != dim {
+			return nil, &DimensionError{Word: word, Expected: dim, Got: len(strVector)}
+		}
+
+		for _, strFloat := range strVector {
+			n, err := strconv.ParseFloat(strFloat, 64)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, n)
+		}
+		words = append(words, word)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
 	}
-	// // fmt.Println("wordVectorMap: %v", wordVectorMap)
 
-	for index, phraseObj := range wordVectorSlice {
-		model.Vocab = append(model.Vocab, phraseObj)
-		model.Word2Index[phraseObj.Literal] = index
+	model := newLoadedModel(words, data, dim)
+	if config != nil {
+		model = applyConfig(model, config.Config)
+		model.Tokenizer = loadedTokenizer(config.Tokenizer)
 	}
+	return model, nil
+}
 
-	// index := model.Word2Index["BC,"]
-	// // fmt.Println("wordVectorMap: %v", model.Vocab[index].Vector)
+// openFile opens path and reports a missing file as MissingFileError
+func openFile(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, &MissingFileError{Path: path, Err: err}
+	}
+	return f, err
+}
 
-	// // fmt.Println(model.Vocab[word])
-	// model.RawVocab[word] = phrase
+// Train train the word2vec model over the sentences
+func Train(sentences [][]string, model *Model) *Model {
+	// a SliceCorpus never fails
+	model, _ = TrainCorpus(SliceCorpus(sentences), model)
+	return model
+}
 
-	// if err != nil {
-	// // fmt.Printf("error opening model data file: %v\n", err)
-	// os.Exit(1)
-	// }
-	//
-	// defer f.Close()
-	//
-	// var size, dim int
-	// _, errDim := fmt.Fscanf(f, "%d", &dim)
-	// _, errSize := fmt.Fscanf(f, "%d", &size)
-	//
-	// // fmt.Println("dim")
-	// // fmt.Println(dim)
-	//
-	// if errDim != nil {
-	// // fmt.Println("error reading file for dim")
-	// // fmt.Println(errDim.Error())
-	// return nil
-	// }
-	//
-	// if errSize != nil {
-	// // fmt.Println("error reading file for size")
-	// // fmt.Println(errSize.Error())
-	// return nil
-	// }
+// TrainCorpus train the word2vec model over the corpus, making one pass per epoch.
+// The sentences are spread over model.Workers goroutines which update Syn0/Syn1/Syn1Neg
+// without locking (Hogwild, like the C tool and gensim). The learning rate decays linearly
+// from model.Alpha with the number of words processed by all workers together.
+// Training starts after the last completed epoch (model.Epoch), so a model restored
+// with LoadCheckpoint resumes where it stopped. If model.CheckpointPath is set a
+// checkpoint is written after every epoch.
+func TrainCorpus(corpus Corpus, model *Model) (*Model, error) {
+	workers := model.Workers
+	if workers < 1 {
+		workers = 1
+	}
 
-	// newReader := bufio.NewReader(f)
-	// newWriter := bufio.NewWriter(f)
-	// newWriter.Write(1.999999999)
+	// model.WordCount is shared by all workers, only accessed through sync/atomic
+	progress := newProgressReporter(model)
 
-	// newReader.ReadString('\n')
+	for epoch := model.Epoch + 1; epoch <= model.Epochs; epoch++ {
+		jobs := make(chan []string, 2*workers)
 
-	// var word string
-	// _, errWord := fmt.Fscanf(f, "%d", &word)
-	//
-	// if errWord != nil {
-	// // fmt.Println("error reading first word")
-	// // fmt.Println(errWord.Error())
-	// return nil
-	// }
-
-	// if n != 2 {
-	// // fmt.Println("could not extract size/dim from binary model data")
-	// return nil
-	// }
-
-	// // fmt.Println("dim")
-	// // fmt.Println(dim)
-	// // fmt.Println("size")
-	// // fmt.Println(size)
-
-	// vocab := make([][]float64, 0)
-
-	//v := make([]float64, 300)
-	//for i := 1; i <= 26; i++ {
-	//if err := binary.Read(br, binary.BigEndian, v); err != nil {
-	// //fmt.Println(err.Error())
-	//return nil
-	//}
-	//
-	// //fmt.Println(v)
-	//vocab = append(vocab, v)
-	//}
-	//
-	// //fmt.Println("Actual vector count")
-	// //fmt.Println(len(vocab))
-	//
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
 
-	// m := &Model{
-	// words: make(map[string]Vector, size),
-	// dim:   dim,
-	// }
+				// derived from seed and epoch, so a resumed epoch starts from the same random state
+				r := rand.New(rand.NewSource(model.Seed + int64(epoch*workers+w)))
+				for sentence := range jobs {
+					loss := trainSentence(model, sentence, &model.WordCount, r)
+					progress.add(epoch, loss, atomic.LoadInt64(&model.WordCount))
+				}
+			}(w)
+		}
 
-	return model
+		err := corpus.Iterate(func(sentence []string) error {
+			if len(sentence) > 0 {
+				jobs <- sentence
+			}
+			return nil
+		})
+		close(jobs)
+		wg.Wait()
+
+		if err != nil {
+			return model, err
+		}
+
+		model.Epoch = epoch
+		progress.epochEnd(epoch)
+
+		if model.CheckpointPath != "" {
+			if err := SaveCheckpoint(model, model.CheckpointPath); err != nil {
+				return model, err
+			}
+		}
+	}
+
+	if model.Buckets > 0 {
+		model = adjustVectors(model)
+	}
+	return InitSims(model), nil
 }
 
-const delim = "?!.;,*\"()'"
+// currentAlpha decays the learning rate linearly from Alpha to MinAlpha over all epochs
+func currentAlpha(model *Model, wordCount int64) float64 {
+	progress := float64(wordCount) / (float64(model.Epochs)*float64(model.TotalCorpusCount) + 1.0)
+	alpha := model.Alpha - (model.Alpha-model.MinAlpha)*progress
 
-func isDelim(c string) bool {
-	if strings.Contains(delim, c) {
-		return true
+	if alpha < model.MinAlpha {
+		alpha = model.MinAlpha
 	}
-	return false
+	return alpha
 }
 
-func cleanString(input string) string {
+// trainSentence subsamples the sentence, trains every remaining word and returns the loss.
+// wordCount is shared between the workers.
+func trainSentence(model *Model, sentence []string, wordCount *int64, r *rand.Rand) float64 {
+	trainSentence, _ := sentencePhrases(model, sentence, wordCount, r)
+	loss := 0.0
+
+	alpha := currentAlpha(model, atomic.LoadInt64(wordCount))
 
-	size := len(input)
-	temp := ""
-	var prevChar string
+	for sentencePosition := range trainSentence {
+		b := reducedWindow(model, r)
 
-	for i := 0; i < size; i++ {
-		// //fmt.Println(input[i])
-		str := string(input[i]) // convert to string for easier operation
-		if (str == " " && prevChar != " ") || !isDelim(str) {
-			temp += str
-			prevChar = str
-		} else if prevChar != " " && isDelim(str) {
-			temp += " "
+		if model.Architecture == CBOW {
+			loss += trainCBOW(model, trainSentence, sentencePosition, b, alpha, r)
+		} else {
+			loss += trainSG(model, trainSentence, sentencePosition, b, alpha, r)
 		}
 	}
-	return temp
-}
 
-// Train train the word2vec model over the corpus
-func Train(sentences [][]string, model *Model) *Model {
+	return loss
+}
 
-	negLabels := make([]float64, model.Negative+1)
-	negLabels = append(negLabels, 1.0)
-	for len(negLabels) < model.Negative+1 {
-		negLabels = append(negLabels, 0.0)
+// reducedWindow returns b, the context of a word is Window - b words on each side
+// C: b = next_random % window
+func reducedWindow(model *Model, r *rand.Rand) int {
+	if !model.ShrinkWindow {
+		return 0
 	}
+	return r.Intn(model.Window)
+}
 
-	startingAlpha := model.Alpha
-	skippedWords := make([]string, 0)
-
-	rand.Seed(model.seed)
-	s2 := rand.NewSource(time.Now().UnixNano())
-	r2 := rand.New(s2)
+// sentencePhrases looks up the words of sentence in the vocabulary and drops frequent words by subsampling,
+// it returns the kept phrases and the number of skipped words
+func sentencePhrases(model *Model, sentence []string, wordCount *int64, r *rand.Rand) ([]*Phrase, int) {
+	phrases := make([]*Phrase, 0)
+	skippedWords := 0
 
-	wordCount := 1
+	for _, word := range sentenceTokens(model, sentence) {
+		if idx, ok := model.Word2Index[word]; ok {
+			atomic.AddInt64(wordCount, 1)
 
-	for epoch := 1; epoch <= model.Epochs; epoch++ {
-		for _, sentence := range sentences {
-			if len(sentence) == 0 {
-				continue
+			// gensim: keep the word with the probability computed by ScaleVocab, scaled to 2^32
+			if int64(r.Uint32()) <= int64(model.Vocab[idx].SampleInt) {
+				phrases = append(phrases, model.Vocab[idx])
+			} else {
+				skippedWords++
 			}
-			trainSentence := make([]*Phrase, 0)
-
-			for _, w := range sentence {
-
-				temp := cleanString(w)
-				word := strings.Replace(temp, " ", "", -1)
-				word = strings.ToLower(word)
-				if idx, ok := model.Word2Index[word]; ok {
-					wordCount++
-
-					ran := (math.Sqrt(float64(model.Vocab[idx].Count)/(model.Sample*float64(wordCount))) + 1) * (model.Sample * float64(wordCount)) / float64(model.Vocab[idx].Count)
-					nextRandom := r2.Float64()
-
-					if ran > nextRandom {
-						trainSentence = append(trainSentence, model.Vocab[idx])
-					} else {
-						skippedWords = append(skippedWords, word)
-					}
-				} else {
-					continue
-				}
+		}
+	}
 
-			}
+	return phrases, skippedWords
+}
 
-			sentencePosition := 0
+// trainSG runs the skip-gram updates for the word at sentencePosition:
+// every context word of the (reduced) window is used to predict the word.
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/word2vec.py (train_sg_pair)
+func trainSG(model *Model, trainSentence []*Phrase, sentencePosition int, b int, alpha float64, r *rand.Rand) float64 {
+	word := trainSentence[sentencePosition]
+	loss := 0.0
 
-			if len(trainSentence) == 0 {
-				continue
-			}
+	for a := b; a < model.Window*2+1-b; a++ {
+		c := sentencePosition - model.Window + a
 
-			for sentencePosition < len(trainSentence) {
-				residual := wordCount % 10000
-				if residual < 10 {
+		if c < 0 || c >= len(trainSentence) {
+			continue
+		}
 
-					model.Alpha = startingAlpha * (1.0 - float64(wordCount)/(float64(epoch)*float64(model.TotalCorpusCount)+1.0))
+		lastWord := trainSentence[c]
 
-					if model.Alpha < startingAlpha*0.0001 {
-						model.Alpha = startingAlpha * 0.0001
-					}
-					fmt.Printf("new Alpha: %v\n", model.Alpha)
-					model.Alpha = 0.025
-				}
+		if lastWord.Id == word.Id {
+			continue
+		}
 
-				word := trainSentence[sentencePosition]
-				s1 := rand.NewSource(time.Now().UnixNano())
-				r1 := rand.New(s1)
+		l1 := inputVector(model, lastWord)
+		neu1e := mat64.NewVector(model.VecDim, nil)
 
-				nextRandom := r1.Intn(10000)
-				b := int(math.Mod(float64(nextRandom), float64(model.Window)))
+		loss += trainOutput(model, word, l1, neu1e, alpha, true, r)
 
-				for a := b; a < model.Window*2+1-b; a++ {
-					c := sentencePosition - model.Window + a
+		updateInput(model, lastWord, neu1e)
+		atomic.AddInt64(&lastWord.Updated, 1)
+	}
+
+	return loss
+}
 
-					if c < 0 {
-						continue
-					}
+func sigmoid(x float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-x))
+}
 
-					if c >= len(trainSentence) {
-						continue
-					}
+// trainCBOW runs a single CBOW update for the word at sentencePosition.
+// Like the C tool the context vectors of the (reduced) window are combined into neu1,
+// the center word is predicted from neu1 via negative sampling and the accumulated
+// error is added back to every context vector.
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/word2vec.py (train_cbow_pair)
+func trainCBOW(model *Model, trainSentence []*Phrase, sentencePosition int, b int, alpha float64, r *rand.Rand) float64 {
+	word := trainSentence[sentencePosition]
 
-					lastWord := trainSentence[c]
+	neu1 := mat64.NewVector(model.VecDim, nil)
+	context := make([]*Phrase, 0)
 
-					if lastWord.Literal == word.Literal {
-						continue
-					}
+	for a := b; a < model.Window*2+1-b; a++ {
+		c := sentencePosition - model.Window + a
 
-					neu1e := mat64.NewVector(model.VecDim, nil)
+		if c < 0 || c >= len(trainSentence) || c == sentencePosition {
+			continue
+		}
 
-					if word.Id == lastWord.Id {
-						continue
-					}
+		lastWord := trainSentence[c]
+		neu1.AddVec(neu1, inputVector(model, lastWord))
+		context = append(context, lastWord)
+	}
 
-					l1 := model.Syn0.RowView(lastWord.Id)
+	if len(context) == 0 {
+		return 0
+	}
 
-					target := 0
-					label := 0
-					for d := 0; d < model.Negative+1; d++ {
+	if model.CBOWMean {
+		neu1.ScaleVec(1.0/float64(len(context)), neu1)
+	}
 
-						if d == 0 {
-							target = word.Id
-							label = 1
-						} else {
+	neu1e := mat64.NewVector(model.VecDim, nil)
 
-							random := int(r1.Intn(len(model.CumTable)))
+	loss := trainOutput(model, word, neu1, neu1e, alpha, true, r)
 
-							target = model.CumTable[random]
+	// gensim: the summed input received the full error, spread it over the context words
+	if !model.CBOWMean {
+		neu1e.ScaleVec(1.0/float64(len(context)), neu1e)
+	}
 
-							if target == word.Id {
-								continue
-							}
+	for _, lastWord := range context {
+		updateInput(model, lastWord, neu1e)
+		atomic.AddInt64(&lastWord.Updated, 1)
+	}
 
-							label = 0
-						}
+	return loss
+}
 
-						l2 := model.Syn1Neg.RowView(target)
-						x := mat64.Dot(l1, l2)
-						networkOutput := float64(1.0) / (1.0 + math.Exp(-x))
+// trainOutput trains the enabled output layers to predict word from l1, accumulates the error in neu1e
+// and returns the loss, with learnHidden false Syn1 and Syn1Neg are left unchanged (used to infer doc2vec vectors)
+func trainOutput(model *Model, word *Phrase, l1 *mat64.Vector, neu1e *mat64.Vector, alpha float64, learnHidden bool, r *rand.Rand) float64 {
+	loss := 0.0
 
-						g := (float64(label) - networkOutput) * model.Alpha
+	if model.HS {
+		loss += trainHS(model, word, l1, neu1e, alpha, learnHidden)
+	}
 
-						neu1eSub := mat64.NewVector(model.VecDim, nil)
-						neu1eSub.ScaleVec(g, l2)
-						neu1e.AddVec(neu1e, neu1eSub)
+	if model.Negative > 0 {
+		loss += trainNegative(model, word, l1, neu1e, alpha, learnHidden, r)
+	}
 
-						//if word.Literal == "computer" {
-						//fmt.Printf("context word: %v\n", lastWord.Literal)
-						//fmt.Printf("negative: %v\n", model.Vocab[target].Literal)
-						//fmt.Printf("x: %v\n", x)
-						//fmt.Printf("x: %v\n", round.ToEven(x, 6))
-						//
-						//fmt.Printf("networkOutput: %v\n", networkOutput)
-						//fmt.Printf("gradient: %v\n", g)
-						//}
+	return loss
+}
 
-						errorHiddenLayerWeights := mat64.NewVector(model.VecDim, nil)
-						errorHiddenLayerWeights.ScaleVec(g, l1)
+// trainNegative predicts word from the hidden layer l1 with negative sampling.
+// Syn1Neg is updated in place, the error for l1 is accumulated into neu1e and the loss is returned.
+func trainNegative(model *Model, word *Phrase, l1 *mat64.Vector, neu1e *mat64.Vector, alpha float64, learnHidden bool, r *rand.Rand) float64 {
+	loss := 0.0
 
-						newOutputLayerWeights := mat64.NewVector(model.VecDim, nil)
-						newOutputLayerWeights.AddVec(model.Syn1Neg.RowView(target), errorHiddenLayerWeights)
-						model.Syn1Neg.SetRow(target, newOutputLayerWeights.RawVector().Data)
-					}
+	for d := 0; d < model.Negative+1; d++ {
+		target := word.Id
+		label := 1.0
 
-					oldWeights := model.Syn0.RowView(lastWord.Id)
-					newWeights := mat64.NewVector(model.VecDim, nil)
-					newWeights.AddVec(oldWeights, neu1e)
-					model.Syn0.SetRow(lastWord.Id, newWeights.RawVector().Data)
-					lastWord.Updated++
-				}
-				sentencePosition += 1
+		if d > 0 {
+			target = sampleNegative(model, r)
+			if target == word.Id {
+				continue
 			}
+			label = 0.0
 		}
-	}
 
-	fmt.Printf("skippedWords size: %d\n", len(skippedWords))
-	fmt.Printf("wordCount: %d\n", wordCount)
-	fmt.Printf("Vocab: %d\n", len(model.Vocab))
-	fmt.Printf("RawVocab: %d\n", len(model.RawVocab))
-	// fmt.Printf("skippedWords: %v\n", skippedWords)
-	return model
+		l2 := model.Syn1Neg.RowView(target)
+		f := mat64.Dot(l1, l2)
+		g := (label - sigmoid(f)) * alpha
+
+		if label > 0 {
+			loss -= logSigmoid(f)
+		} else {
+			loss -= logSigmoid(-f)
+		}
+
+		neu1e.AddScaledVec(neu1e, g, l2)
+		if learnHidden {
+			l2.AddScaledVec(l2, g, l1)
+		}
+	}
 
+	return loss
 }
@@ -6,8 +6,12 @@ import (
 	"github.com/gonum/matrix/mat64"
 	"gopkg.in/neurosnap/sentences.v1"
 	"gopkg.in/neurosnap/sentences.v1/data"
+	"io/ioutil"
 	// "log"
+	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -19,8 +23,36 @@ var (
 	tinySentences = [][]string{sentenceOne, sentenceTwo}
 )
 
+// testConfig is the configuration of the training tests: one worker, hierarchical softmax and
+// no subsampling, so every word of tinySentences is trained in every epoch
+func testConfig(epochs int) Config {
+	config := DefaultConfig()
+	config.Epochs = epochs
+	config.MinCount = 1
+	config.VecDim = 10
+	config.Workers = 1
+	config.HS = true
+	config.Negative = 0
+	config.Sample = 1.0
+	return config
+}
+
+// testModel initializes a model with config and builds the vocabulary of sentences
+func testModel(t *testing.T, config Config, sentences [][]string) *Model {
+	model, err := InitModelConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = BuildVocab(sentences, model)
+	model = ScaleVocab(model)
+	return FinalizeVocab(model)
+}
+
 func TestBuildVocab(t *testing.T) {
-	model := InitModel(1, 1, 10)
+	model, err := InitModel(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
 	fmt.Println("TestBuildVocab")
 	model = BuildVocab(tinySentences, model)
 	if len(model.RawVocab) != 6 {
@@ -30,7 +62,10 @@ func TestBuildVocab(t *testing.T) {
 
 func TestScaleVocab(t *testing.T) {
 	fmt.Println("TestScaleVocab")
-	model := InitModel(1, 1, 10)
+	model, err := InitModel(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
 	model = BuildVocab(tinySentences, model)
 	model = ScaleVocab(model)
 	if len(model.Vocab) != 6 {
@@ -38,6 +73,189 @@ func TestScaleVocab(t *testing.T) {
 	}
 }
 
+func TestMakeCumTable(t *testing.T) {
+	fmt.Println("TestMakeCumTable")
+	model := newModel(1, 1, 10)
+	model.Vocab = Vocab{{Literal: "a", Count: 16}, {Literal: "b", Count: 1}, {Literal: "c", Count: 81}}
+
+	for _, exponent := range []float64{0.75, 0, 1} {
+		model.NSExponent = exponent
+		model = makeCumTable(model)
+
+		if len(model.CumTable) != 3 || model.CumTable[2] != cumTableDomain {
+			t.Fatalf("Got table %v instead of 3 entries ending in %d", model.CumTable, cumTableDomain)
+		}
+
+		total := 0.0
+		for _, phraseObj := range model.Vocab {
+			total += math.Pow(float64(phraseObj.Count), exponent)
+		}
+
+		counts := make([]int, 3)
+		r := rand.New(rand.NewSource(1))
+		draws := 100000
+		for i := 0; i < draws; i++ {
+			counts[sampleNegative(model, r)]++
+		}
+
+		for index, phraseObj := range model.Vocab {
+			expected := math.Pow(float64(phraseObj.Count), exponent) / total
+			if got := float64(counts[index]) / float64(draws); math.Abs(got-expected) > 0.01 {
+				t.Errorf("Exponent %v: drew %s with %f instead of %f", exponent, phraseObj.Literal, got, expected)
+			}
+		}
+	}
+}
+
+func TestTrainCBOW(t *testing.T) {
+	fmt.Println("TestTrainCBOW")
+	config := testConfig(2)
+	config.Architecture = CBOW
+	config.HS = false
+	config.Negative = 5
+	model := testModel(t, config, tinySentences)
+
+	before := mat64.DenseCopyOf(model.Syn0)
+	model = Train(tinySentences, model)
+
+	if mat64.Equal(before, model.Syn0) {
+		t.Errorf("CBOW training did not update Syn0")
+	}
+
+	r, c := model.Syn1Neg.Dims()
+	if mat64.Equal(model.Syn1Neg, mat64.NewDense(r, c, nil)) {
+		t.Errorf("CBOW training did not update Syn1Neg")
+	}
+}
+
+func TestTrainWorkers(t *testing.T) {
+	fmt.Println("TestTrainWorkers")
+	sens := make([][]string, 0)
+	for i := 0; i < 50; i++ {
+		sens = append(sens, tinySentences...)
+	}
+
+	config := testConfig(3)
+	config.Workers = 4
+	model := testModel(t, config, sens)
+
+	before := mat64.DenseCopyOf(model.Syn0)
+	model = Train(sens, model)
+
+	if mat64.Equal(before, model.Syn0) {
+		t.Errorf("Training with %d workers did not update Syn0", model.Workers)
+	}
+
+	// every sentence must have been trained: each word is a context word in every epoch
+	for _, phraseObj := range model.Vocab {
+		if phraseObj.Updated < int64(phraseObj.Count) {
+			t.Errorf("%s was updated %d times for %d occurrences", phraseObj.Literal, phraseObj.Updated, phraseObj.Count)
+		}
+	}
+}
+
+func TestTrainDeterministic(t *testing.T) {
+	fmt.Println("TestTrainDeterministic")
+	sens := make([][]string, 0)
+	for i := 0; i < 20; i++ {
+		sens = append(sens, tinySentences...)
+	}
+
+	train := func(seed int64) *Model {
+		// subsampling and negative sampling both draw from the seeded random source
+		config := testConfig(3)
+		config.HS = false
+		config.Negative = 5
+		config.Sample = 0.01
+		config.Seed = seed
+		return Train(sens, testModel(t, config, sens))
+	}
+
+	first, second := train(1), train(1)
+	for index := range first.Vocab {
+		if first.Vocab[index].Literal != second.Vocab[index].Literal {
+			t.Fatalf("Vocab order differs at %d: %s and %s", index, first.Vocab[index].Literal, second.Vocab[index].Literal)
+		}
+	}
+	if !mat64.Equal(first.Syn0, second.Syn0) || !mat64.Equal(first.Syn1Neg, second.Syn1Neg) {
+		t.Errorf("Two runs with the same seed gave different weights")
+	}
+
+	if other := train(2); mat64.Equal(first.Syn0, other.Syn0) {
+		t.Errorf("Different seeds gave the same weights")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	fmt.Println("TestLoadErrors")
+	if _, err := Load("does-not-exist.txt"); err == nil {
+		t.Errorf("Expected an error for a missing model")
+	} else if _, ok := err.(*MissingFileError); !ok {
+		t.Errorf("Got %T instead of *MissingFileError", err)
+	}
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	malformed := filepath.Join(dir, "malformed.txt")
+	ioutil.WriteFile(malformed, []byte("five two\nwant\n0.1 0.2\n"), 0644)
+	if _, err := Load(malformed); err == nil {
+		t.Errorf("Expected an error for a malformed header")
+	} else if _, ok := err.(*HeaderError); !ok {
+		t.Errorf("Got %T instead of *HeaderError", err)
+	}
+
+	oversized := filepath.Join(dir, "oversized.txt")
+	ioutil.WriteFile(oversized, []byte("300 99999999999\nwant\n0.1 0.2\n"), 0644)
+	if _, err := Load(oversized); err == nil {
+		t.Errorf("Expected an error for a header announcing more words than the file holds")
+	} else if _, ok := err.(*HeaderError); !ok {
+		t.Errorf("Got %T instead of *HeaderError", err)
+	}
+
+	mismatch := filepath.Join(dir, "mismatch.txt")
+	ioutil.WriteFile(mismatch, []byte("3 1\nwant\n0.1 0.2\n"), 0644)
+	if _, err := Load(mismatch); err == nil {
+		t.Errorf("Expected an error for a dimension mismatch")
+	} else if _, ok := err.(*DimensionError); !ok {
+		t.Errorf("Got %T instead of *DimensionError", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	fmt.Println("TestSaveLoad")
+	model, err := InitModel(1, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = BuildVocab(tinySentences, model)
+	model = ScaleVocab(model)
+	model = resetWeights(model)
+
+	dir, err := ioutil.TempDir("", "word2vec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tiny-model-save.txt")
+	if err := Save(model, path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded.Vocab) != 6 || loaded.VecDim != 5 {
+		t.Errorf("Got %d words of dimension %d instead of 6 words of dimension 5", len(loaded.Vocab), loaded.VecDim)
+	}
+}
+
 // func TestFinalizeVocab(t *testing.T) {
 // fmt.Println("TestFinalizeVocab")
 // model := InitModel(1, 1)
@@ -79,7 +297,10 @@ func TestScaleVocab(t *testing.T) {
 // }
 
 func TestPreMostSimilar(t *testing.T) {
-	model := InitModel(1, 5, 50)
+	model, err := InitModel(1, 5, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	//f, err := os.Open("wiki-data/eng_wikipedia_2012_1M-sentences.txt")
 	//f, err := os.Open("wiki-data/eng_news_2015_100K-sentences.txt")
@@ -138,7 +359,10 @@ func TestPreMostSimilar(t *testing.T) {
 
 func TestTrainCorpus(t *testing.T) {
 	fmt.Println("TestTrainCorpus")
-	model := InitModel(5, 20, 50)
+	model, err := InitModel(5, 20, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	//f, err := os.Open("wiki-data/eng_wikipedia_2012_1M-sentences.txt")
 	//f, err := os.Open("wiki-data/eng_news_2015_100K-sentences.txt")
@@ -186,7 +410,9 @@ func TestTrainCorpus(t *testing.T) {
 	//model = Save(model, "100k_model_5-min-count_5_epochs.txt")
 	//model = Save(model, "big_50_epochs.txt")
 	//model = Save(model, "4-keyword_model.txt")
-	model = Save(model, "tiny_model.txt")
+	if err := Save(model, "tiny_model.txt"); err != nil {
+		t.Fatal(err)
+	}
 
 }
 
@@ -224,7 +450,10 @@ func TestTrainCorpus(t *testing.T) {
 
 func TestMostSimilar(t *testing.T) {
 	//model := Load("1M_model.txt")
-	model := Load("1M_model_final.txt")
+	model, err := Load("1M_model_final.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
 	//model := Load("gensim-model.txt")
 	//model := Load("4-keyword_model.txt")
 
@@ -256,7 +485,10 @@ func TestMostSimilar(t *testing.T) {
 }
 
 func TestMostSimilarByVector(t *testing.T) {
-	model := Load("1M_model_final.txt")
+	model, err := Load("1M_model_final.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
 	//model := Load("gensim-model.txt")
 	//model := Load("4-keyword_model.txt")
 
@@ -278,7 +510,10 @@ func TestMostSimilarByVector(t *testing.T) {
 
 func TestVectorCalcs(t *testing.T) {
 	fmt.Println("TestVectorCalcs")
-	model := Load("100k_model_5-min-count_5_epochs.txt")
+	model, err := Load("100k_model_5-min-count_5_epochs.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// queen ≈ king − man + woman
 	// washington ≈ berlin − germany + usa
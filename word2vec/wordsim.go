@@ -0,0 +1,157 @@
+package word2vec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Word similarity benchmarks like WordSim-353 or SimLex-999 in the tab separated
+// "word1 word2 score" format, lines starting with # are comments.
+// python: https://github.com/RaRe-Technologies/gensim/blob/develop/gensim/models/keyedvectors.py (evaluate_word_pairs)
+
+// SimilarityReport compares the cosine similarities of the model with human scores
+type SimilarityReport struct {
+	Pearson  float64
+	Spearman float64
+	Pairs    int // word pairs in the benchmark
+	OOV      int // pairs skipped because a word is out of vocabulary
+}
+
+// Coverage returns the share of pairs with both words in the vocabulary
+func (r SimilarityReport) Coverage() float64 {
+	if r.Pairs == 0 {
+		return 0
+	}
+	return float64(r.Pairs-r.OOV) / float64(r.Pairs)
+}
+
+func (r SimilarityReport) String() string {
+	return fmt.Sprintf("pearson %.4f, spearman %.4f, coverage %.4f (%d pairs, %d out of vocabulary)",
+		r.Pearson, r.Spearman, r.Coverage(), r.Pairs, r.OOV)
+}
+
+// EvaluateWordPairs scores the model against the benchmark file at path
+func EvaluateWordPairs(path string, model *Model) (*SimilarityReport, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return EvaluateWordPairsReader(f, model)
+}
+
+// EvaluateWordPairsReader scores the model against the benchmark read from r.
+// A first line whose score is not a number is skipped as header, words are looked up as given
+// or in the form of the tokenizer of the model.
+func EvaluateWordPairsReader(r io.Reader, model *Model) (*SimilarityReport, error) {
+	report := &SimilarityReport{}
+	human := make([]float64, 0)
+	cosine := make([]float64, 0)
+
+	first := true
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		header := first
+		first = false
+
+		fields := strings.Split(text, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("word2vec: expected word1, word2 and score on line %d", line)
+		}
+
+		score, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err != nil {
+			if header {
+				continue
+			}
+			return nil, fmt.Errorf("word2vec: expected a score in the third column on line %d, got %q", line, fields[2])
+		}
+
+		report.Pairs++
+
+		idx1, ok1 := lookupWord(model, strings.TrimSpace(fields[0]))
+		idx2, ok2 := lookupWord(model, strings.TrimSpace(fields[1]))
+		if !ok1 || !ok2 {
+			report.OOV++
+			continue
+		}
+
+		human = append(human, score)
+		cosine = append(cosine, Similarity(model.Vocab[idx1].Vector, model.Vocab[idx2].Vector, model))
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if report.Pairs == 0 {
+		return nil, fmt.Errorf("word2vec: no word pairs in the benchmark")
+	}
+
+	report.Pearson = pearson(human, cosine)
+	report.Spearman = pearson(ranks(human), ranks(cosine))
+
+	return report, nil
+}
+
+// pearson returns the correlation coefficient of x and y, NaN for less than two values
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	if len(x) < 2 {
+		return math.NaN()
+	}
+
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	cov, varX, varY := 0.0, 0.0, 0.0
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	return cov / math.Sqrt(varX*varY)
+}
+
+// ranks returns the 1-based rank of every value, ties get the average of their ranks
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	r := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			r[order[k]] = rank
+		}
+		i = j + 1
+	}
+	return r
+}
@@ -0,0 +1,62 @@
+package word2vec
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRanks(t *testing.T) {
+	fmt.Println("TestRanks")
+	got := ranks([]float64{10, 30, 20, 20})
+	expected := []float64{1, 4, 2.5, 2.5}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Got ranks %v instead of %v", got, expected)
+			break
+		}
+	}
+}
+
+func TestEvaluateWordPairs(t *testing.T) {
+	fmt.Println("TestEvaluateWordPairs")
+	pairs := `# Word 1	Word 2	Human (mean)
+Word 1	Word 2	Human (mean)
+king	queen	6.0
+king	man	8.5
+man	apple	0.5
+king	pear	1.0
+king	unicorn	7.0
+`
+	report, err := EvaluateWordPairsReader(strings.NewReader(pairs), analogyModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Pairs != 5 || report.OOV != 1 {
+		t.Errorf("Got %d pairs and %d out of vocabulary instead of 5 and 1", report.Pairs, report.OOV)
+	}
+	if math.Abs(report.Coverage()-0.8) > 1e-9 {
+		t.Errorf("Got coverage %f instead of 0.8", report.Coverage())
+	}
+	if math.Abs(report.Spearman-1.0) > 1e-9 {
+		t.Errorf("Got spearman %f instead of 1", report.Spearman)
+	}
+	if report.Pearson < 0.5 {
+		t.Errorf("Got pearson %f, expected a positive correlation", report.Pearson)
+	}
+
+	if _, err := EvaluateWordPairsReader(strings.NewReader("king queen 8.5\n"), analogyModel()); err == nil {
+		t.Errorf("Expected an error for a line that is not tab separated")
+	}
+
+	// raw SimLex-999 has the part of speech in the third column
+	simlex := "word1\tword2\tPOS\tSimLex999\nking\tqueen\tN\t6.0\n"
+	if _, err := EvaluateWordPairsReader(strings.NewReader(simlex), analogyModel()); err == nil {
+		t.Errorf("Expected an error for a score column that is not a number")
+	}
+	if _, err := EvaluateWordPairsReader(strings.NewReader("word1\tword2\tscore\n"), analogyModel()); err == nil {
+		t.Errorf("Expected an error for a benchmark without pairs")
+	}
+}